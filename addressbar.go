@@ -0,0 +1,39 @@
+package main
+
+import "strings"
+
+// normalizeURL turns address bar input into a URL. Local paths become
+// file: URLs; anything else defaults to https like fetchURL does.
+func normalizeURL(input string) string {
+	input = strings.TrimSpace(input)
+	if input == "" || strings.Contains(input, "://") || isDataURI(input) {
+		return input
+	}
+	if looksLikePath(input) {
+		if target, err := fileURL(input); err == nil {
+			return target
+		}
+	}
+	return "https://" + input
+}
+
+// looksLikeURL reports whether command line input is an address rather
+// than a command: a single word containing a dot, a scheme or a port.
+func looksLikeURL(input string) bool {
+	input = strings.TrimSpace(input)
+	if input == "" || strings.ContainsAny(input, " \t") {
+		return false
+	}
+	return strings.Contains(input, "://") || strings.Contains(input, ".") || strings.HasPrefix(input, "localhost")
+}
+
+// openAddressBar prompts for a URL, prefilled with the current one, and
+// opens it.
+func (b *browser) openAddressBar() {
+	b.prompt("URL: ", b.currentURL, func(text string) {
+		if target := normalizeURL(text); target != "" {
+			b.openURL(target)
+		}
+	})
+	b.enableOmniboxAutocomplete()
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/rivo/tview"
+)
+
+// scrollToAnchor scrolls to the element fragment names, as following a
+// #fragment link does, without fetching the page again.
+func (b *browser) scrollToAnchor(fragment string) {
+	line, ok := b.anchors[fragment]
+	if !ok {
+		// Fragments in links may be percent-encoded
+		if unescaped, err := url.PathUnescape(fragment); err == nil {
+			line, ok = b.anchors[unescaped]
+		}
+	}
+	if !ok {
+		b.setStatus(fmt.Sprintf("No anchor #%s on this page", tview.Escape(fragment)))
+		return
+	}
+	b.scrollTo(b.displayRow(line))
+	b.setStatus("#" + tview.Escape(fragment))
+}
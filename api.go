@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+type apiResponse struct {
+	Status      string
+	ContentType string
+	Body        string
+}
+
+// sendRequest issues an arbitrary HTTP request with an optional body. Unlike
+// fetchURL it accepts any status code, since error responses are usually
+// what you want to see when exploring an API.
+func sendRequest(method, targetURL, contentType, body string) (*apiResponse, error) {
+	parsedURL, err := url.Parse(targetURL)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing URL: %v", err)
+	}
+
+	if parsedURL.Scheme == "" {
+		parsedURL.Scheme = "https"
+	}
+
+	var reqBody io.Reader
+	if body != "" {
+		reqBody = strings.NewReader(body)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), navigationTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(method), parsedURL.String(), reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	if body != "" && contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	setBasicAuth(req)
+
+	resp, err := httpClient.Do(req)
+	if isTimeout(err) {
+		return nil, errTimeout
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if isTimeout(err) {
+		return nil, errTimeout
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %v", err)
+	}
+
+	return &apiResponse{
+		Status:      resp.Status,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        string(respBody),
+	}, nil
+}
+
+// prettyJSON indents body when it is valid JSON and returns it unchanged
+// otherwise.
+func prettyJSON(body string) string {
+	var out bytes.Buffer
+	if err := json.Indent(&out, []byte(body), "", "  "); err != nil {
+		return body
+	}
+	return out.String()
+}
+
+// requestCommand prompts for a content type and body, then sends a request
+// with the given method and shows the raw response in place of the page.
+func (b *browser) requestCommand(args string) {
+	fields := strings.Fields(args)
+	if len(fields) != 2 {
+		b.setStatus("Usage: :" + commands["request"].usage)
+		return
+	}
+	method, targetURL := strings.ToUpper(fields[0]), fields[1]
+
+	b.prompt("Content-Type: ", "application/json", func(contentType string) {
+		b.prompt("Body: ", "", func(body string) {
+			b.setStatus(fmt.Sprintf("Sending %s %s…", method, tview.Escape(displayURL(targetURL))))
+			go func() {
+				resp, err := sendRequest(method, targetURL, contentType, body)
+				b.app.QueueUpdateDraw(func() {
+					if err != nil {
+						b.setStatus(err.Error())
+						return
+					}
+					b.showResponse(method, targetURL, resp)
+				})
+			}()
+		})
+	})
+}
+
+func (b *browser) showResponse(method, targetURL string, resp *apiResponse) {
+	text := resp.Body
+	if strings.Contains(resp.ContentType, "json") || json.Valid([]byte(text)) {
+		text = prettyJSON(text)
+	}
+
+	b.setPage(&Page{Text: tview.Escape(text)})
+	b.textView.ScrollToBeginning()
+	b.setStatus(fmt.Sprintf("%s %s → %s (%s)", method, targetURL, resp.Status, resp.ContentType))
+}
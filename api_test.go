@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSendRequestPostsJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		if got := r.Header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", got)
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "alice" || pass != "secret" {
+			t.Errorf("basic auth = %q, %q, %v; want alice, secret", user, pass, ok)
+		}
+		var payload map[string]string
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &payload); err != nil || payload["name"] != "widget" {
+			t.Errorf("body = %q, want the JSON that was sent", body)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{"id":7,"name":"widget"}`)
+	}))
+	defer server.Close()
+
+	target := strings.Replace(server.URL, "http://", "http://alice:secret@", 1) + "/items"
+	resp, err := sendRequest("post", target, "application/json", `{"name":"widget"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != "201 Created" {
+		t.Errorf("Status = %q, want 201 Created", resp.Status)
+	}
+	if resp.ContentType != "application/json" {
+		t.Errorf("ContentType = %q", resp.ContentType)
+	}
+	want := "{\n  \"id\": 7,\n  \"name\": \"widget\"\n}"
+	if got := prettyJSON(resp.Body); got != want {
+		t.Errorf("prettyJSON(body) = %q, want %q", got, want)
+	}
+}
+
+func TestSendRequestTimesOut(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	defer func(timeout time.Duration) { navigationTimeout = timeout }(navigationTimeout)
+	navigationTimeout = 100 * time.Millisecond
+
+	if _, err := sendRequest("DELETE", server.URL, "", ""); !errors.Is(err, errTimeout) {
+		t.Errorf("err = %v, want %v", err, errTimeout)
+	}
+}
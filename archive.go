@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+)
+
+// statusError is returned by fetchURL when the server answers with a
+// non-200 status.
+type statusError struct {
+	Code   int
+	Status string
+}
+
+func (e *statusError) Error() string {
+	return "bad status: " + e.Status
+}
+
+// isHardFailure reports whether err means the page is gone or unreachable,
+// as opposed to e.g. an authorization problem an archive cannot help with.
+func isHardFailure(err error) bool {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code == http.StatusNotFound || statusErr.Code == http.StatusGone ||
+			statusErr.Code >= http.StatusInternalServerError
+	}
+	return err != nil
+}
+
+// fetchWithFallback fetches targetURL and, when the archive fallback is
+// enabled and the fetch fails hard, retries through the configured archive
+// URL template. It returns the fetched page, whose redirect chain ends with
+// the URL it was actually served from, and whether that is an archived copy.
+func fetchWithFallback(targetURL string) (*fetchResult, bool, error) {
+	result, err := fetchPage(targetURL)
+	if err == nil || !config.ArchiveFallback || !isHardFailure(err) || isLocalURL(targetURL) {
+		return result, false, err
+	}
+
+	archiveURL, expandErr := expandSnippet(config.ArchiveTemplate, displayURL(targetURL))
+	if expandErr != nil {
+		return nil, false, err
+	}
+
+	archived, archiveErr := fetchPage(archiveURL)
+	if archiveErr != nil {
+		// Report the original failure; it is the one the user cares about
+		return nil, false, err
+	}
+	return archived, true, nil
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+var exportDir = "exports"
+
+// articleTags are the elements kept when exporting an article. Any other
+// element is dropped but its children are still visited.
+var articleTags = map[string]bool{
+	"p": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ul": true, "ol": true, "li": true, "dl": true, "dt": true, "dd": true,
+	"blockquote": true, "pre": true, "code": true, "em": true, "strong": true,
+	"b": true, "i": true, "a": true, "img": true, "figure": true, "figcaption": true,
+	"table": true, "thead": true, "tbody": true, "tr": true, "th": true, "td": true,
+	"br": true, "hr": true,
+}
+
+// articleSkip are subtrees that never belong to the main content.
+var articleSkip = map[string]bool{
+	"script": true, "style": true, "nav": true, "aside": true, "form": true,
+	"footer": true, "header": true, "noscript": true, "iframe": true, "svg": true,
+}
+
+const articleStyle = `body{max-width:40em;margin:2em auto;padding:0 1em;font:18px/1.6 Georgia,serif;color:#222}
+img{max-width:100%;height:auto}pre{overflow:auto;background:#f4f4f4;padding:.5em}
+blockquote{border-left:3px solid #ccc;margin-left:0;padding-left:1em;color:#555}
+table{border-collapse:collapse}td,th{border:1px solid #ccc;padding:.2em .5em}`
+
+// findArticle picks the node holding the main content: the <article>
+// with the most text, a <main> or role="main" element, or failing that the
+// element whose direct <p> children contain the most text.
+func findArticle(doc *html.Node) *html.Node {
+	var article, main, best *html.Node
+	articleLength, bestScore := 0, 0
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if articleSkip[n.Data] {
+				return
+			}
+			switch {
+			case n.Data == "article":
+				if length := len(nodeText(n)); article == nil || length > articleLength {
+					article, articleLength = n, length
+				}
+			case (n.Data == "main" || getAttr(n, "role") == "main") && main == nil:
+				main = n
+			}
+
+			score := 0
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if c.Type == html.ElementNode && c.Data == "p" {
+					score += len(nodeText(c))
+				}
+			}
+			if score > bestScore {
+				best, bestScore = n, score
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	switch {
+	case article != nil:
+		return article
+	case main != nil:
+		return main
+	}
+	return best
+}
+
+func getAttr(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+func extractTitle(doc *html.Node) string {
+	var title string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if title != "" {
+			return
+		}
+		if n.Type == html.ElementNode && n.Data == "title" {
+			title = nodeText(n)
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return title
+}
+
+// inArticle reports whether n is inside an <article>, where a <header>
+// holds the article's own title and byline rather than site navigation.
+func inArticle(n *html.Node) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && p.Data == "article" {
+			return true
+		}
+	}
+	return false
+}
+
+// writeArticleHTML writes the cleaned content of n as HTML, keeping only
+// articleTags and resolving link and image URLs against baseURL.
+func writeArticleHTML(out *strings.Builder, n *html.Node, baseURL string) {
+	switch n.Type {
+	case html.TextNode:
+		out.WriteString(template.HTMLEscapeString(n.Data))
+		return
+	case html.ElementNode:
+		if articleSkip[n.Data] && !(n.Data == "header" && inArticle(n)) {
+			return
+		}
+	}
+
+	keep := n.Type == html.ElementNode && articleTags[n.Data]
+	if keep {
+		out.WriteString("<" + n.Data)
+		switch n.Data {
+		case "a":
+			if href := getAttr(n, "href"); href != "" {
+				fmt.Fprintf(out, ` href="%s"`, template.HTMLEscapeString(resolveURL(baseURL, href)))
+			}
+		case "img":
+			if src := getAttr(n, "src"); src != "" {
+				fmt.Fprintf(out, ` src="%s"`, template.HTMLEscapeString(resolveURL(baseURL, src)))
+			}
+			fmt.Fprintf(out, ` alt="%s"`, template.HTMLEscapeString(getAttr(n, "alt")))
+		}
+		out.WriteString(">")
+		if n.Data == "img" || n.Data == "br" || n.Data == "hr" {
+			return
+		}
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeArticleHTML(out, c, baseURL)
+	}
+
+	if keep {
+		out.WriteString("</" + n.Data + ">")
+	}
+}
+
+// exportArticle writes a standalone, styled HTML copy of the main content
+// of htmlContent into exportDir and returns the file's path.
+func exportArticle(htmlContent, pageURL string) (string, error) {
+	doc, err := html.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		return "", fmt.Errorf("error parsing HTML: %v", err)
+	}
+
+	article := findArticle(doc)
+	if article == nil {
+		return "", fmt.Errorf("no article content found")
+	}
+
+	title := extractTitle(doc)
+	if title == "" {
+		title = pageURL
+	}
+
+	var out strings.Builder
+	out.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&out, "<title>%s</title>\n<style>%s</style>\n</head>\n<body>\n", template.HTMLEscapeString(title), articleStyle)
+	fmt.Fprintf(&out, "<h1>%s</h1>\n<p><small>Source: <a href=\"%s\">%s</a></small></p>\n",
+		template.HTMLEscapeString(title), template.HTMLEscapeString(pageURL), template.HTMLEscapeString(pageURL))
+	writeArticleHTML(&out, article, documentBase(doc, pageURL))
+	out.WriteString("\n</body>\n</html>\n")
+
+	if err := os.MkdirAll(exportDir, 0755); err != nil {
+		return "", fmt.Errorf("error creating export dir: %v", err)
+	}
+	filename := filepath.Join(exportDir, fmt.Sprintf("article_%s.html", time.Now().Format("20060102_150405")))
+	if err := os.WriteFile(filename, []byte(out.String()), 0644); err != nil {
+		return "", fmt.Errorf("error writing export: %v", err)
+	}
+	return filename, nil
+}
+
+func (b *browser) exportCurrentArticle() {
+	if b.source == "" {
+		b.setStatus("No page loaded")
+		return
+	}
+
+	filename, err := exportArticle(b.source, b.currentURL)
+	if err != nil {
+		b.setStatus(err.Error())
+		return
+	}
+	b.setStatus("Exported article to " + filename)
+}
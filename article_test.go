@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestFindArticlePrefersTheLongestArticle(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><body>
+		<header><p>Site name</p></header>
+		<article><p>Teaser for another story.</p></article>
+		<article>
+			<header><h2>The real story</h2><p>By A. Writer</p></header>
+			<p>The first paragraph of the story, which is far longer than the teaser above it.</p>
+			<p>A second paragraph.</p>
+		</article>
+		<footer><p>Copyright</p></footer>
+	</body></html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	article := findArticle(doc)
+	if article == nil || !strings.Contains(nodeText(article), "The real story") {
+		t.Fatalf("findArticle picked %q, want the longest article", nodeText(article))
+	}
+
+	var out strings.Builder
+	writeArticleHTML(&out, article, "https://example.com/")
+	got := out.String()
+	for _, want := range []string{"<h2>The real story</h2>", "<p>By A. Writer</p>", "A second paragraph."} {
+		if !strings.Contains(got, want) {
+			t.Errorf("article HTML %q is missing %q", got, want)
+		}
+	}
+	for _, unwanted := range []string{"Teaser", "Site name", "Copyright"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("article HTML %q contains %q from outside the article", got, unwanted)
+		}
+	}
+}
+
+func TestArticleHTMLSkipsHeadersOutsideArticles(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<main><header><p>Site menu</p></header><p>Body text.</p></main>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	writeArticleHTML(&out, findArticle(doc), "https://example.com/")
+	if got := out.String(); strings.Contains(got, "Site menu") || !strings.Contains(got, "Body text.") {
+		t.Errorf("article HTML = %q, want the body text without the page header", got)
+	}
+}
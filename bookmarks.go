@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+type Bookmark struct {
+	URL   string `json:"url"`
+	Title string `json:"title"`
+}
+
+// bookmarks is loaded at startup and saved whenever it changes.
+var bookmarks []Bookmark
+
+func bookmarksFile() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "bookmarks.json"), nil
+}
+
+// loadBookmarks reads the saved bookmarks. A missing file is not an error
+// and yields no bookmarks.
+func loadBookmarks() ([]Bookmark, error) {
+	filename, err := bookmarksFile()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []Bookmark{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading bookmarks: %v", err)
+	}
+
+	var loaded []Bookmark
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return nil, fmt.Errorf("error parsing bookmarks: %v", err)
+	}
+	return loaded, nil
+}
+
+func saveBookmarks(list []Bookmark) error {
+	filename, err := bookmarksFile()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding bookmarks: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return fmt.Errorf("error creating config dir: %v", err)
+	}
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		return fmt.Errorf("error writing bookmarks: %v", err)
+	}
+	return nil
+}
+
+// addBookmark bookmarks the current page. Credentials in its URL are left
+// out, since bookmarks are saved in plain text.
+func (b *browser) addBookmark() {
+	if b.currentURL == "" {
+		return
+	}
+	pageURL := displayURL(b.currentURL)
+	for _, bookmark := range bookmarks {
+		if bookmark.URL == pageURL {
+			b.setStatus("Already bookmarked")
+			return
+		}
+	}
+
+	title := b.title
+	if title == "" {
+		title = pageURL
+	}
+	bookmarks = append(bookmarks, Bookmark{URL: pageURL, Title: title})
+	if err := saveBookmarks(bookmarks); err != nil {
+		b.setStatus(err.Error())
+		return
+	}
+	b.setStatus("Bookmarked " + title)
+}
+
+// showBookmarks lists the bookmarks. Enter opens one and d deletes it.
+func (b *browser) showBookmarks() {
+	if len(bookmarks) == 0 {
+		b.setStatus("No bookmarks yet (press b to add one)")
+		return
+	}
+
+	list := tview.NewList()
+	for _, bookmark := range bookmarks {
+		list.AddItem(tview.Escape(bookmark.Title), activeTheme.muted+tview.Escape(bookmark.URL), 0, nil)
+	}
+
+	list.SetSelectedFunc(func(index int, _, _ string, _ rune) {
+		b.closeOverlay("bookmarks")
+		b.openURL(bookmarks[index].URL)
+	})
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEscape || event.Rune() == 'q':
+			b.closeOverlay("bookmarks")
+			return nil
+		case event.Rune() == 'd':
+			index := list.GetCurrentItem()
+			bookmarks = append(bookmarks[:index], bookmarks[index+1:]...)
+			list.RemoveItem(index)
+			if err := saveBookmarks(bookmarks); err != nil {
+				b.setStatus(err.Error())
+			}
+			if len(bookmarks) == 0 {
+				b.closeOverlay("bookmarks")
+			}
+			return nil
+		}
+		return event
+	})
+
+	list.SetBorder(true).SetTitle(" bookmarks (Enter: open, d: delete) ")
+	b.showOverlay("bookmarks", list)
+}
@@ -0,0 +1,658 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+type browser struct {
+	app         *tview.Application
+	pages       *tview.Pages
+	layout      *tview.Flex
+	titleView   *tview.TextView
+	content     *tview.Pages
+	textView    *tview.TextView
+	columns     *columnLayout
+	scroller    *smoothScroller
+	statusView  *tview.TextView
+	promptField *tview.InputField
+	bar         *tview.Pages
+	debugView   *tview.TextView
+	showDebug   bool
+
+	currentURL string
+	back       []string
+	forward    []string
+	source     string
+	title      string
+	words      int
+	text       string
+	shown      string
+	truncated  bool
+	findQuery  string
+	findIndex  int
+	findCount  int
+	linkNumber string
+	links      []LinkInfo
+	images     []ImageInfo
+	tables     []TableInfo
+	headings   []HeadingInfo
+	anchors    map[string]int
+	imageIndex int
+	nextURL    string
+	search     *SearchForm
+	forms      []Form
+	visits     []visit
+	graph      *linkGraph
+
+	// responseStatus and responseHeader describe the HTTP response the
+	// current page came from.
+	responseStatus string
+	responseHeader http.Header
+
+	// selectedLink indexes links; -1 means no link is selected.
+	selectedLink int
+
+	// inlineImages renders images as ASCII art within the page. plainPage
+	// is the page without them while they are shown.
+	inlineImages bool
+	plainPage    *Page
+	cancelImages context.CancelFunc
+
+	// clicked is set while a mouse click has not yet been resolved to a
+	// link or an image.
+	clicked bool
+
+	// refreshes counts meta refreshes followed in a row.
+	refreshes int
+
+	// loadedPages holds the URLs shown in the current view, including
+	// appended next pages. appended lists those next pages in order.
+	loadedPages map[string]bool
+	appended    []appendedNext
+
+	// expanded is set while collapsible blocks are shown in full.
+	expanded bool
+
+	// tabs are the open tabs. The entry at activeTab is only brought up
+	// to date when switching away from it.
+	tabs      []tab
+	activeTab int
+}
+
+func newBrowser() *browser {
+	b := &browser{app: tview.NewApplication(), imageIndex: -1, selectedLink: -1, loadedPages: make(map[string]bool), tabs: []tab{{}}}
+	b.scroller = &smoothScroller{b: b}
+	b.graph = newLinkGraph()
+
+	b.textView = tview.NewTextView().
+		SetDynamicColors(true).
+		SetRegions(true).
+		SetWordWrap(true).
+		SetTextStyle(tcell.StyleDefault.Background(tcell.ColorDefault).Foreground(tcell.ColorDefault))
+
+	b.titleView = tview.NewTextView().SetDynamicColors(true)
+	b.statusView = tview.NewTextView().SetDynamicColors(true)
+	b.promptField = tview.NewInputField()
+	b.bar = tview.NewPages().
+		AddPage("status", b.statusView, true, true).
+		AddPage("prompt", b.promptField, true, false)
+
+	b.debugView = tview.NewTextView().SetDynamicColors(true)
+	b.debugView.SetBorder(true).SetTitle(" debug ")
+
+	b.columns = newColumnLayout()
+	b.columns.left.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event = b.columns.handleKey(event); event == nil {
+			return nil
+		}
+		return b.handleKey(event)
+	})
+
+	b.content = tview.NewPages().
+		AddPage("text", b.textView, true, true).
+		AddPage("columns", b.columns.flex, true, false)
+
+	b.layout = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(b.titleView, 1, 0, false).
+		AddItem(b.content, 0, 1, true).
+		AddItem(b.bar, 1, 0, false)
+
+	b.pages = tview.NewPages().AddPage("browser", b.layout, true, true)
+	b.app.SetAfterDrawFunc(b.drawProgress)
+
+	b.textView.SetInputCapture(b.handleKey)
+	b.textView.SetMouseCapture(b.handleMouse)
+	b.textView.SetHighlightedFunc(b.linkClicked)
+
+	return b
+}
+
+func (b *browser) handleKey(event *tcell.EventKey) *tcell.EventKey {
+	// Tab keys come first so Alt+digit is not taken for a link number
+	if event = b.handleTabKey(event); event == nil {
+		return nil
+	}
+	if event = b.handleLinkNumber(event); event == nil {
+		return nil
+	}
+
+	switch event.Key() {
+	case tcell.KeyEscape:
+		b.app.Stop()
+		return nil
+	case tcell.KeyF12:
+		b.toggleDebug()
+		return nil
+	case tcell.KeyTab:
+		b.cycleLink(1)
+		return nil
+	case tcell.KeyBacktab:
+		b.cycleLink(-1)
+		return nil
+	case tcell.KeyEnter:
+		if link, ok := b.currentLink(); ok {
+			b.navigate(link.Href)
+			return nil
+		}
+	case tcell.KeyF5:
+		b.reload()
+		return nil
+	case tcell.KeyHome:
+		b.scrollTo(0)
+		return nil
+	case tcell.KeyEnd:
+		b.scrollTo(-1)
+		return nil
+	case tcell.KeyCtrlD:
+		b.scrollHalfPage(1)
+		return nil
+	case tcell.KeyCtrlU:
+		b.scrollHalfPage(-1)
+		return nil
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		b.goBack()
+		return nil
+	case tcell.KeyRune:
+		switch event.Rune() {
+		case ':':
+			b.openCommandLine()
+			return nil
+		case 'g':
+			b.scrollTo(0)
+			return nil
+		case 'G':
+			b.scrollTo(-1)
+			return nil
+		case '/':
+			b.openFind()
+			return nil
+		case 'e':
+			b.openAddressBar()
+			return nil
+		case 'r':
+			b.reload()
+			return nil
+		case 'b':
+			b.addBookmark()
+			return nil
+		case 'B':
+			b.showBookmarks()
+			return nil
+		case 'H':
+			b.showHistory()
+			return nil
+		case 'h':
+			b.goBack()
+			return nil
+		case 'l':
+			b.goForward()
+			return nil
+		case 'n':
+			b.cycleMatch(1)
+			return nil
+		case 'N':
+			b.cycleMatch(-1)
+			return nil
+		case 'm':
+			b.cycleImage(1)
+			return nil
+		case 'M':
+			b.cycleImage(-1)
+			return nil
+		case 'i':
+			b.toggleInlineImages()
+			return nil
+		case 'v':
+			b.viewCurrentImage()
+			return nil
+		case 'V':
+			b.viewImageInView()
+			return nil
+		case 'w':
+			b.saveCurrentImage()
+			return nil
+		case 'W':
+			b.saveAllImages()
+			return nil
+		case 't':
+			b.viewTable()
+			return nil
+		case 'T':
+			b.showHeadings()
+			return nil
+		case 'x':
+			b.toggleCollapsed()
+			return nil
+		case 's':
+			b.searchSite()
+			return nil
+		case 'L':
+			b.loadRest()
+			return nil
+		case 'Y':
+			b.copyPageText()
+			return nil
+		case ']':
+			b.appendNextPage()
+			return nil
+		case '}':
+			b.followNextPage()
+			return nil
+		case '=':
+			b.diffWithCached()
+			return nil
+		case 'C':
+			b.toggleColumns()
+			return nil
+		case 'P':
+			b.exportCurrentArticle()
+			return nil
+		case 'p':
+			b.saveMarkdown()
+			return nil
+		case 'S':
+			b.savePage()
+			return nil
+		case 'o':
+			b.openInBrowser()
+			return nil
+		case 'f':
+			b.showForms()
+			return nil
+		case 'I':
+			b.toggleHeaders()
+			return nil
+		case 'd':
+			b.downloadLink()
+			return nil
+		case 'u':
+			b.exportList("links", "")
+			return nil
+		case 'U':
+			b.exportList("images", "")
+			return nil
+		case 'y':
+			b.copyURL()
+			return nil
+		}
+	}
+	return b.handleScrollKey(event)
+}
+
+// handleMouse lets the text view find the link region under a click,
+// which accounts for word wrapping and several links on one line. The
+// region is followed in linkClicked; clicks that miss every link open an
+// image rendered on that line instead.
+func (b *browser) handleMouse(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
+	if action != tview.MouseLeftClick {
+		return action, event
+	}
+
+	// Clear the selection so clicking the selected link still reports it
+	b.textView.Highlight()
+	b.selectedLink = -1
+	b.clicked = true
+
+	_, rectY, _, _ := b.textView.GetInnerRect()
+	_, y := event.Position()
+	scrollOffset, _ := b.textView.GetScrollOffset()
+	line := b.logicalLine(y - rectY + scrollOffset)
+	b.app.QueueUpdateDraw(func() {
+		if !b.clicked {
+			return
+		}
+		b.clicked = false
+		if index := b.imageOnLine(line); index >= 0 {
+			b.imageIndex = index
+			b.viewCurrentImage()
+		}
+	})
+	return action, event
+}
+
+// logicalLine converts a row of the word-wrapped text view into the line
+// of the displayed text it belongs to.
+func (b *browser) logicalLine(row int) int {
+	_, _, width, _ := b.textView.GetInnerRect()
+	return wrappedLine(b.textView.GetText(false), width, row)
+}
+
+// wrappedLine returns the line of text that row falls on when text is
+// word-wrapped at width columns, or -1 when row is past the end.
+func wrappedLine(text string, width, row int) int {
+	if width <= 0 {
+		return row
+	}
+	for line, content := range strings.Split(text, "\n") {
+		rows := wrappedRows(content, width)
+		if row < rows {
+			return line
+		}
+		row -= rows
+	}
+	return -1
+}
+
+// displayRow converts a line of the displayed text into the row of the
+// word-wrapped text view it starts on; the inverse of logicalLine.
+func (b *browser) displayRow(line int) int {
+	_, _, width, _ := b.textView.GetInnerRect()
+	return wrappedRow(b.textView.GetText(false), width, line)
+}
+
+// wrappedRow returns the row that line of text starts on when text is
+// word-wrapped at width columns.
+func wrappedRow(text string, width, line int) int {
+	if width <= 0 {
+		return line
+	}
+	row := 0
+	for i, content := range strings.Split(text, "\n") {
+		if i >= line {
+			break
+		}
+		row += wrappedRows(content, width)
+	}
+	return row
+}
+
+// wrappedRows returns how many rows line takes up when word-wrapped at
+// width columns. Empty lines still take one.
+func wrappedRows(line string, width int) int {
+	return max(len(tview.WordWrap(regionTagPattern.ReplaceAllString(line, ""), width)), 1)
+}
+
+// navigate opens targetURL as a new history entry, dropping any pages
+// that could be reached with forward. Pages from the network cannot open
+// local files.
+func (b *browser) navigate(targetURL string) {
+	if !localFileAllowed(b.currentURL, targetURL) {
+		b.setStatus(errLocalFile.Error())
+		return
+	}
+	b.openURL(targetURL)
+}
+
+// openURL is navigate for addresses the user entered or picked from their
+// own bookmarks and history, which may open local files from any page.
+func (b *browser) openURL(targetURL string) {
+	if message, ok := checkNavigable(targetURL); !ok {
+		b.setStatus(message)
+		return
+	}
+	if fragment, ok := samePageFragment(b.currentURL, targetURL); ok && b.source != "" {
+		b.scrollToAnchor(fragment)
+		return
+	}
+	b.refreshes = 0
+	b.load(targetURL, true, func() {
+		if b.currentURL != "" && b.currentURL != targetURL {
+			b.back = append(b.back, b.currentURL)
+			b.forward = nil
+		}
+	})
+}
+
+// reload fetches the current page again, bypassing the cache.
+func (b *browser) reload() {
+	if b.currentURL == "" {
+		return
+	}
+	b.load(b.currentURL, false, nil)
+}
+
+// load fetches targetURL in the background. With useCache it is served
+// from the session cache when the page has been rendered before. The
+// current page stays current until the new one is shown, when onLoad, if
+// not nil, is called first to update history; a failed load leaves both
+// untouched.
+func (b *browser) load(targetURL string, useCache bool, onLoad func()) {
+	b.rememberPosition()
+	b.setStatus(activeTheme.muted + "Loading " + tview.Escape(displayURL(targetURL)) + "…[-::-]")
+	go func() {
+		if cached, ok := getCachedPage(targetURL); ok && useCache {
+			b.showPage(targetURL, cached, onLoad)
+			return
+		}
+
+		result, archived, err := fetchWithFallback(targetURL)
+		if err != nil {
+			if errors.Is(err, errTimeout) {
+				b.showError("Request timed out")
+				return
+			}
+			b.showError(fmt.Sprintf("Error fetching URL: %v", err))
+			return
+		}
+
+		chain := result.Chain
+		sourceURL := chain[len(chain)-1]
+		page, err := renderHTML(result.Body, sourceURL)
+		if err != nil {
+			b.showError(fmt.Sprintf("Error rendering HTML: %v", err))
+			return
+		}
+		page.Status, page.Header = result.Status, result.Header
+
+		if archived {
+			b.showPage(targetURL, page, onLoad)
+			b.app.QueueUpdateDraw(func() {
+				b.setStatus("Page unavailable, showing archived copy from " + sourceURL)
+			})
+			return
+		}
+
+		putCachedPage(targetURL, page)
+		if len(chain) > 1 {
+			// Show the page under the URL it was served from so history
+			// and relative links refer to where we actually landed
+			putCachedPage(sourceURL, page)
+			b.showPage(sourceURL, page, onLoad)
+			b.app.QueueUpdateDraw(func() {
+				hops := make([]string, len(chain))
+				for i, hop := range chain {
+					hops[i] = displayURL(hop)
+				}
+				b.setStatus("Redirected: " + strings.Join(hops, " → "))
+			})
+			return
+		}
+		b.showPage(targetURL, page, onLoad)
+	}()
+}
+
+func (b *browser) showPage(pageURL string, page *Page, onLoad func()) {
+	historyErr := appendHistory(HistoryEntry{URL: pageURL, Title: page.Title})
+	b.app.QueueUpdateDraw(func() {
+		if onLoad != nil {
+			onLoad()
+		}
+		b.currentURL = pageURL
+		b.setStatus(tview.Escape(displayURL(pageURL)))
+		b.stopInlineImages()
+		b.setPage(b.quietPage(pageURL, page))
+		b.restorePosition(pageURL)
+		if _, fragment, ok := strings.Cut(pageURL, "#"); ok && fragment != "" {
+			b.scrollToAnchor(fragment)
+		}
+		if b.inlineImages {
+			b.renderInlineImages()
+		}
+		b.loadedPages = map[string]bool{pageURL: true}
+		b.appended = nil
+		b.expanded = false
+		b.recordVisit(pageURL)
+		b.graph.addPage(pageURL, page.Links)
+		b.followRefresh(pageURL, page)
+		if historyErr != nil {
+			b.setStatus(historyErr.Error())
+		}
+	})
+}
+
+// setPage replaces the displayed content and everything derived from it.
+// It must run on the UI goroutine.
+func (b *browser) setPage(page *Page) {
+	shown, truncated := truncateText(page.Text, config.TruncateLines)
+	b.textView.SetText(shown)
+	b.columns.setText(shown)
+	b.text = page.Text
+	b.shown = shown
+	b.truncated = truncated
+	b.findCount = 0
+	b.links = page.Links
+	b.images = page.Images
+	b.tables = page.Tables
+	b.headings = page.Headings
+	b.anchors = page.Anchors
+	b.source = page.Source
+	b.title = page.Title
+	b.words = page.Words
+	b.updateTitle()
+	b.nextURL = page.Next
+	b.search = page.Search
+	b.forms = page.Forms
+	b.responseStatus, b.responseHeader = page.Status, page.Header
+	b.imageIndex = -1
+	b.selectedLink = -1
+	b.plainPage = nil
+	b.updateDebug()
+}
+
+// updateTitle shows the page title in the header bar, or the URL when the
+// page has none.
+func (b *browser) updateTitle() {
+	title := b.title
+	if title == "" {
+		title = displayURL(b.currentURL)
+	}
+	text := "[::b]" + tview.Escape(title) + "[-::-]"
+	if b.source != "" {
+		text += "  " + activeTheme.muted + readingStats(b.words) + "[-::-]"
+	}
+	b.titleView.SetText(text)
+}
+
+// showError reports a failed load in the status bar, leaving the page
+// that was shown before in place.
+func (b *browser) showError(message string) {
+	b.app.QueueUpdateDraw(func() {
+		b.setStatus(activeTheme.errorTag + tview.Escape(message) + "[-::-]")
+	})
+}
+
+func (b *browser) setStatus(message string) {
+	b.statusView.SetText(message)
+}
+
+// prompt replaces the status bar with an input field and calls done with
+// the entered text when the user presses Enter. Escape cancels the prompt.
+func (b *browser) prompt(label, initial string, done func(text string)) {
+	b.promptField.SetAutocompleteFunc(nil).SetAutocompletedFunc(nil).SetChangedFunc(nil)
+	b.promptField.SetLabel(label).SetText(initial)
+	b.promptField.SetDoneFunc(func(key tcell.Key) {
+		text := b.promptField.GetText()
+		b.closePrompt()
+		if key == tcell.KeyEnter {
+			done(text)
+		}
+	})
+	b.bar.SwitchToPage("prompt")
+	b.app.SetFocus(b.promptField)
+}
+
+func (b *browser) closePrompt() {
+	b.bar.SwitchToPage("status")
+	b.app.SetFocus(b.textView)
+}
+
+// showOverlay displays p on top of the page until closeOverlay is called
+// with the same name.
+func (b *browser) showOverlay(name string, p tview.Primitive) {
+	b.pages.AddPage(name, p, true, true)
+	b.app.SetFocus(p)
+}
+
+func (b *browser) closeOverlay(name string) {
+	b.pages.RemovePage(name)
+	b.app.SetFocus(b.textView)
+}
+
+func (b *browser) toggleDebug() {
+	b.showDebug = !b.showDebug
+	if b.showDebug {
+		b.updateDebug()
+		b.layout.AddItem(b.debugView, 7, 0, false)
+	} else {
+		b.layout.RemoveItem(b.debugView)
+	}
+}
+
+func (b *browser) updateDebug() {
+	if !b.showDebug {
+		return
+	}
+	used, budget, entries, evictions := sessionCache.Stats()
+	b.debugView.SetText(fmt.Sprintf(
+		"URL:    %s\nKey:    %s\nCache:  %s / %s (%d entries, %d evicted)\nPage:   %d links, %d images",
+		b.currentURL, pageKey(b.currentURL), formatBytes(used), formatBytes(budget), entries, evictions,
+		len(b.links), len(b.images)))
+}
+
+func browseInteractive(initialURL string, level colorLevel) error {
+	b := newBrowser()
+	if err := loadPositions(); err != nil {
+		fmt.Println(err)
+	}
+
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		return err
+	}
+	if err := screen.Init(); err != nil {
+		return err
+	}
+	if level == colorAuto {
+		level = detectColorLevel(screen)
+	}
+	setColorLevel(level)
+	renderWidth, _ = screen.Size()
+	b.app.SetScreen(screen)
+
+	// Initial page load
+	b.openURL(initialURL)
+
+	if err := b.app.SetRoot(b.pages, true).EnableMouse(true).Run(); err != nil {
+		return err
+	}
+
+	b.rememberPosition()
+	return savePositions()
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestWrappedLine(t *testing.T) {
+	text := strings.Join([]string{
+		"short",
+		"",
+		"a line long enough to wrap onto three rows",
+		`[gray][1[][-::-]["link-1"]link[""] after`,
+	}, "\n")
+	// At width 16 the third line wraps into three rows
+	tests := []struct {
+		row, line int
+	}{
+		{0, 0},
+		{1, 1},
+		{2, 2}, {3, 2}, {4, 2},
+		{5, 3},
+		{6, -1},
+	}
+	for _, tt := range tests {
+		if got := wrappedLine(text, 16, tt.row); got != tt.line {
+			t.Errorf("wrappedLine(row %d) = %d, want %d", tt.row, got, tt.line)
+		}
+	}
+	if got := wrappedLine(text, 0, 4); got != 4 {
+		t.Errorf("wrappedLine without a width = %d, want the row unchanged", got)
+	}
+}
+
+func TestWrappedRow(t *testing.T) {
+	text := strings.Join([]string{
+		"short",
+		"",
+		"a line long enough to wrap onto three rows",
+		"last",
+	}, "\n")
+	for line, row := range []int{0, 1, 2, 5} {
+		if got := wrappedRow(text, 16, line); got != row {
+			t.Errorf("wrappedRow(line %d) = %d, want %d", line, got, row)
+		}
+		if got := wrappedLine(text, 16, row); got != line {
+			t.Errorf("wrappedLine(wrappedRow(%d)) = %d, want the line back", line, got)
+		}
+	}
+	if got := wrappedRow(text, 0, 3); got != 3 {
+		t.Errorf("wrappedRow without a width = %d, want the line unchanged", got)
+	}
+}
+
+func TestLinkLinesMatchWrappedText(t *testing.T) {
+	doc := `<html><body>
+		<p>An opening paragraph that is much longer than the narrow view it is shown in, so it wraps.</p>
+		<p>Text before <a href="/one">the first link</a> and after it.</p>
+		<ul><li>Item with <a href="/two">second</a></li><li>Plain item</li></ul>
+		<pre>pre
+formatted
+<a href="/three">third</a></pre>
+		<p>Closing text then <a href="/four">a fourth link whose text is long enough to wrap</a></p>
+	</body></html>`
+	for _, whitespace := range []string{"lines", "html"} {
+		t.Run(whitespace, func(t *testing.T) {
+			defer func(before string) { config.Whitespace = before }(config.Whitespace)
+			config.Whitespace = whitespace
+
+			page, err := renderHTML(doc, "https://example.com/")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(page.Links) != 4 {
+				t.Fatalf("got %d links, want 4", len(page.Links))
+			}
+			lines := strings.Split(page.Text, "\n")
+			for _, link := range page.Links {
+				if link.Line < 0 || link.Line >= len(lines) || !strings.Contains(lines[link.Line], regionTag(link)) {
+					t.Errorf("link %q has Line %d, which does not contain it", link.Text, link.Line)
+					continue
+				}
+				// The first wrapped row of the link's line maps back to it
+				row := 0
+				for _, line := range lines[:link.Line] {
+					row += wrappedRows(line, 20)
+				}
+				if got := wrappedLine(page.Text, 20, row); got != link.Line {
+					t.Errorf("row %d of link %q maps to line %d, want %d", row, link.Text, got, link.Line)
+				}
+			}
+		})
+	}
+}
+
+// regionTag returns the opening region tag of link in the page text.
+func regionTag(link LinkInfo) string {
+	return `["link-` + strconv.Itoa(link.Index) + `"]`
+}
+
+func TestFailedLoadKeepsCurrentPage(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatal(err)
+	}
+	b := newBrowser()
+	b.app.SetScreen(screen).SetRoot(b.pages, true)
+	b.currentURL = "file:///start.html"
+	b.back = []string{"file:///before.html"}
+	done := make(chan error, 1)
+	go func() { done <- b.app.Run() }()
+	defer func() {
+		b.app.Stop()
+		<-done
+	}()
+
+	missing := "file://" + filepath.ToSlash(filepath.Join(t.TempDir(), "missing.html"))
+	b.app.QueueUpdate(func() { b.navigate(missing) })
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var status, current string
+		var back []string
+		b.app.QueueUpdate(func() {
+			status, current, back = b.statusView.GetText(true), b.currentURL, b.back
+		})
+		if strings.Contains(status, "Error fetching URL") {
+			if current != "file:///start.html" {
+				t.Errorf("currentURL = %q after a failed load, want the page still shown", current)
+			}
+			if len(back) != 1 || back[0] != "file:///before.html" {
+				t.Errorf("back = %q after a failed load, want it unchanged", back)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("load did not fail in time; status %q", status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"container/list"
+	"fmt"
+	"image"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// memoryCache is an LRU cache bounded by the estimated size of its entries
+// rather than by their count. Rendered pages and decoded images share one
+// budget so a long session cannot grow without limit.
+type memoryCache struct {
+	mu        sync.Mutex
+	budget    int64
+	used      int64
+	order     *list.List
+	items     map[string]*list.Element
+	evictions int
+}
+
+type cacheEntry struct {
+	key   string
+	value interface{}
+	size  int64
+}
+
+var sessionCache = newMemoryCache(64 << 20)
+
+// canonicalKeys maps URLs that were fetched to the canonical URL their page
+// declared, so tracking-laden variants share a single cache entry. Only
+// canonicals naming the same document are followed: listings often give
+// every page of results the first page as canonical.
+var (
+	canonicalMu   sync.Mutex
+	canonicalKeys = make(map[string]string)
+)
+
+// documentURL returns pageURL without its fragment, which only selects a
+// place within the same document.
+func documentURL(pageURL string) string {
+	document, _, _ := strings.Cut(pageURL, "#")
+	return document
+}
+
+// pageKey returns the URL a page is stored under: its canonical URL when
+// one has been seen for pageURL, otherwise pageURL itself. Fragments are
+// ignored.
+func pageKey(pageURL string) string {
+	pageURL = documentURL(pageURL)
+	canonicalMu.Lock()
+	defer canonicalMu.Unlock()
+
+	if canonical, ok := canonicalKeys[pageURL]; ok {
+		return canonical
+	}
+	return pageURL
+}
+
+// trackingParams are query parameters that only identify where a visitor
+// came from, not what the page shows.
+var trackingParams = map[string]bool{
+	"fbclid": true, "gclid": true, "dclid": true, "msclkid": true, "mc_cid": true, "mc_eid": true,
+}
+
+// sameDocument reports whether a and b name the same document, ignoring
+// fragments, a trailing slash, a default port and tracking parameters.
+func sameDocument(a, b string) bool {
+	return normalizeDocumentURL(a) == normalizeDocumentURL(b)
+}
+
+func normalizeDocumentURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	u.Scheme, u.Host = strings.ToLower(u.Scheme), strings.ToLower(u.Host)
+	if port := u.Port(); u.Scheme == "http" && port == "80" || u.Scheme == "https" && port == "443" {
+		u.Host = strings.TrimSuffix(u.Host, ":"+port)
+	}
+	u.Fragment, u.RawFragment = "", ""
+	u.Path, u.RawPath = strings.TrimSuffix(u.Path, "/"), ""
+
+	query := u.Query()
+	for name := range query {
+		if trackingParams[strings.ToLower(name)] || strings.HasPrefix(strings.ToLower(name), "utm_") {
+			query.Del(name)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+func getCachedPage(pageURL string) (*Page, bool) {
+	cached, ok := sessionCache.Get("page:" + pageKey(pageURL))
+	if !ok {
+		return nil, false
+	}
+	return cached.(*Page), true
+}
+
+func putCachedPage(pageURL string, page *Page) {
+	pageURL = documentURL(pageURL)
+	key := pageURL
+	if page.Canonical != "" && sameDocument(pageURL, page.Canonical) {
+		canonicalMu.Lock()
+		canonicalKeys[pageURL] = page.Canonical
+		canonicalMu.Unlock()
+		key = page.Canonical
+	}
+	sessionCache.Put("page:"+key, page, estimatePageSize(page))
+}
+
+func newMemoryCache(budget int64) *memoryCache {
+	return &memoryCache{
+		budget: budget,
+		order:  list.New(),
+		items:  make(map[string]*list.Element),
+	}
+}
+
+func (c *memoryCache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*cacheEntry).value, true
+}
+
+// Put stores value under key and evicts least-recently-used entries until
+// the cache fits its budget again. Entries larger than the whole budget are
+// not stored at all.
+func (c *memoryCache) Put(key string, value interface{}, size int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		c.removeElement(elem)
+	}
+	if size > c.budget {
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&cacheEntry{key: key, value: value, size: size})
+	c.used += size
+	c.evict()
+}
+
+func (c *memoryCache) SetBudget(budget int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.budget = budget
+	c.evict()
+}
+
+func (c *memoryCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.items = make(map[string]*list.Element)
+	c.used = 0
+}
+
+// Stats reports the estimated bytes in use, the budget, the number of
+// entries and how many entries have been evicted so far.
+func (c *memoryCache) Stats() (used, budget int64, entries, evictions int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.used, c.budget, c.order.Len(), c.evictions
+}
+
+func (c *memoryCache) evict() {
+	for c.used > c.budget {
+		oldest := c.order.Back()
+		if oldest == nil {
+			return
+		}
+		c.removeElement(oldest)
+		c.evictions++
+	}
+}
+
+func (c *memoryCache) removeElement(elem *list.Element) {
+	entry := c.order.Remove(elem).(*cacheEntry)
+	delete(c.items, entry.key)
+	c.used -= entry.size
+}
+
+func estimatePageSize(page *Page) int64 {
+	size := int64(len(page.Text) + len(page.Source) + len(page.Canonical) + len(page.Next) + len(page.Title))
+	for _, link := range page.Links {
+		size += int64(len(link.Text)+len(link.Href)) + 32
+	}
+	for _, img := range page.Images {
+		size += int64(len(img.Src)+len(img.Alt)) + 32
+		for _, candidate := range img.Candidates {
+			size += int64(len(candidate)) + 16
+		}
+	}
+	for _, heading := range page.Headings {
+		size += int64(len(heading.Text)+len(heading.ID)) + 32
+	}
+	for _, table := range page.Tables {
+		for _, row := range table.Rows {
+			for _, cell := range row {
+				size += int64(len(cell)) + 16
+			}
+		}
+	}
+	return size
+}
+
+func estimateImageSize(img image.Image) int64 {
+	bounds := img.Bounds()
+	return int64(bounds.Dx()) * int64(bounds.Dy()) * 4
+}
+
+func decodeImageCached(filename string) (image.Image, error) {
+	key := "image:" + filename
+	if cached, ok := sessionCache.Get(key); ok {
+		return cached.(image.Image), nil
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error opening image: %v", err)
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding image: %v", err)
+	}
+
+	sessionCache.Put(key, img, estimateImageSize(img))
+	return img, nil
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GiB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
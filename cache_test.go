@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMemoryCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newMemoryCache(100)
+	for i := 0; i < 4; i++ {
+		cache.Put(fmt.Sprintf("page%d", i), i, 25)
+	}
+	// Touching page0 makes page1 the least recently used entry
+	if _, ok := cache.Get("page0"); !ok {
+		t.Fatal("page0 missing before the budget was exceeded")
+	}
+	cache.Put("page4", 4, 25)
+
+	if _, ok := cache.Get("page1"); ok {
+		t.Error("page1 was not evicted")
+	}
+	for _, key := range []string{"page0", "page2", "page3", "page4"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("%s was evicted", key)
+		}
+	}
+	used, budget, entries, evictions := cache.Stats()
+	if used != 100 || budget != 100 || entries != 4 || evictions != 1 {
+		t.Errorf("Stats() = %d, %d, %d, %d; want 100, 100, 4, 1", used, budget, entries, evictions)
+	}
+}
+
+func TestMemoryCacheSkipsOversizedEntries(t *testing.T) {
+	cache := newMemoryCache(100)
+	cache.Put("small", 1, 10)
+	cache.Put("huge", 2, 101)
+
+	if _, ok := cache.Get("huge"); ok {
+		t.Error("an entry larger than the budget was stored")
+	}
+	if _, ok := cache.Get("small"); !ok {
+		t.Error("an oversized entry evicted the others")
+	}
+}
+
+func TestMemoryCacheShrinkingBudgetEvicts(t *testing.T) {
+	cache := newMemoryCache(100)
+	cache.Put("old", 1, 40)
+	cache.Put("new", 2, 40)
+	cache.SetBudget(50)
+
+	if _, ok := cache.Get("old"); ok {
+		t.Error("old survived a budget below the cache's usage")
+	}
+	if _, ok := cache.Get("new"); !ok {
+		t.Error("new was evicted although it fits the budget")
+	}
+}
+
+func TestCanonicalURLDeduplicatesCachedPages(t *testing.T) {
+	doc := `<html><head><link rel="canonical" href="/story?id=7"></head><body><p>Story</p></body></html>`
+	variants := []string{
+		"https://news.example/story?id=7&utm_source=feed",
+		"https://news.example/story?id=7&utm_source=mail#comments",
+	}
+
+	_, _, entriesBefore, _ := sessionCache.Stats()
+	for _, variant := range variants {
+		page, err := renderHTML(doc, variant)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Canonical != "https://news.example/story?id=7" {
+			t.Fatalf("Canonical = %q", page.Canonical)
+		}
+		putCachedPage(variant, page)
+	}
+	if _, _, entries, _ := sessionCache.Stats(); entries != entriesBefore+1 {
+		t.Errorf("cache grew by %d entries, want the variants to share one", entries-entriesBefore)
+	}
+
+	for _, key := range append(variants, "https://news.example/story?id=7") {
+		if pageKey(key) != "https://news.example/story?id=7" {
+			t.Errorf("pageKey(%q) = %q", key, pageKey(key))
+		}
+		if _, ok := getCachedPage(key); !ok {
+			t.Errorf("no cached page for %q", key)
+		}
+	}
+}
+
+func TestCanonicalURLMustShareOrigin(t *testing.T) {
+	tests := []struct {
+		href, want string
+	}{
+		{"https://news.example/a", "https://news.example/a"},
+		{"/a#top", "https://news.example/a"},
+		{"https://other.example/a", ""},
+		{"http://news.example/a", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		doc := `<html><head><link rel="canonical" href="` + tt.href + `"></head></html>`
+		page, err := renderHTML(doc, "https://news.example/a?ref=1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Canonical != tt.want {
+			t.Errorf("canonical %q gave %q, want %q", tt.href, page.Canonical, tt.want)
+		}
+	}
+}
+
+func TestCanonicalOfAnotherPageIsNotShared(t *testing.T) {
+	doc := func(n string) string {
+		return `<html><head><link rel="canonical" href="/list"></head><body><p>Results page ` + n + `</p></body></html>`
+	}
+	for _, n := range []string{"1", "2", "3"} {
+		pageURL := "https://shop.example/list?page=" + n
+		page, err := renderHTML(doc(n), pageURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		putCachedPage(pageURL, page)
+	}
+	for _, n := range []string{"1", "2", "3"} {
+		page, ok := getCachedPage("https://shop.example/list?page=" + n)
+		if !ok || !strings.Contains(page.Text, "Results page "+n) {
+			t.Errorf("cached page %s = %v, %v; want its own results", n, ok, page)
+		}
+	}
+}
+
+func TestSameDocument(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"https://news.example/story?id=7&utm_source=feed#top", "https://news.example/story?id=7", true},
+		{"https://news.example:443/story/", "https://news.example/story", true},
+		{"HTTPS://News.Example/story?fbclid=x", "https://news.example/story", true},
+		{"https://news.example/story?b=2&a=1", "https://news.example/story?a=1&b=2", true},
+		{"https://shop.example/list?page=2", "https://shop.example/list", false},
+		{"https://news.example:8443/story", "https://news.example/story", false},
+	}
+	for _, tt := range tests {
+		if got := sameDocument(tt.a, tt.b); got != tt.want {
+			t.Errorf("sameDocument(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/rivo/tview"
+)
+
+// copyOSC52 asks the terminal to place text on the system clipboard using
+// the OSC 52 escape sequence, which also works over SSH.
+func copyOSC52(text string) error {
+	encoded := base64.StdEncoding.EncodeToString([]byte(text))
+	sequence := "\x1b]52;c;" + encoded + "\a"
+	if os.Getenv("TMUX") != "" {
+		// tmux only forwards the sequence when wrapped in a DCS passthrough
+		sequence = "\x1bPtmux;\x1b" + sequence + "\x1b\\"
+	}
+
+	if _, err := fmt.Fprint(os.Stdout, sequence); err != nil {
+		return fmt.Errorf("error writing to terminal: %v", err)
+	}
+	return nil
+}
+
+// clipboardCommands are the native clipboard tools tried, in order, before
+// falling back to OSC 52.
+func clipboardCommands() [][]string {
+	switch runtime.GOOS {
+	case "darwin":
+		return [][]string{{"pbcopy"}}
+	case "windows":
+		return [][]string{{"clip.exe"}}
+	}
+	return [][]string{
+		{"wl-copy"},
+		{"xclip", "-selection", "clipboard"},
+		{"xsel", "--clipboard", "--input"},
+		{"clip.exe"}, // WSL
+	}
+}
+
+// copyToClipboard places text on the system clipboard with the first
+// native clipboard tool available, or with OSC 52 when there is none.
+func copyToClipboard(text string) error {
+	for _, args := range clipboardCommands() {
+		path, err := exec.LookPath(args[0])
+		if err != nil {
+			continue
+		}
+		cmd := exec.Command(path, args[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err == nil {
+			return nil
+		}
+	}
+	return copyOSC52(text)
+}
+
+// copyURL copies the selected link, or the current page's URL when no
+// link is selected.
+func (b *browser) copyURL() {
+	target := b.currentURL
+	if link, ok := b.currentLink(); ok {
+		target = link.Href
+	}
+	if target == "" {
+		b.setStatus("No page loaded")
+		return
+	}
+
+	target = displayURL(target)
+	if err := copyToClipboard(target); err != nil {
+		b.setStatus(err.Error())
+		return
+	}
+	b.setStatus("Copied " + tview.Escape(target))
+}
+
+// osc52Limit is the largest payload, after base64 encoding, that terminals
+// reliably accept in one OSC 52 sequence. xterm and tmux both drop or
+// truncate larger ones.
+const osc52Limit = 100000
+
+// copyPageText copies the rendered page without markup. Pages too large
+// for OSC 52 are offered to be saved as a text file instead.
+func (b *browser) copyPageText() {
+	text := stripTags(b.text)
+	if base64.StdEncoding.EncodedLen(len(text)) > osc52Limit {
+		b.prompt(fmt.Sprintf("Page is %s, too large for the clipboard. Save to file? (y/n) ", formatBytes(int64(len(text)))), "", func(answer string) {
+			if !strings.EqualFold(strings.TrimSpace(answer), "y") {
+				return
+			}
+			filename, err := savePageText(text)
+			if err != nil {
+				b.setStatus(err.Error())
+				return
+			}
+			b.setStatus("Saved page text to " + filename)
+		})
+		return
+	}
+
+	if err := copyOSC52(text); err != nil {
+		b.setStatus(err.Error())
+		return
+	}
+	b.setStatus(fmt.Sprintf("Copied page text (%s)", formatBytes(int64(len(text)))))
+}
+
+// savePageText writes text into exportDir and returns the file's path.
+func savePageText(text string) (string, error) {
+	if err := os.MkdirAll(exportDir, 0755); err != nil {
+		return "", fmt.Errorf("error creating export dir: %v", err)
+	}
+	filename := filepath.Join(exportDir, fmt.Sprintf("page_%s.txt", time.Now().Format("20060102_150405")))
+	if err := os.WriteFile(filename, []byte(text), 0644); err != nil {
+		return "", fmt.Errorf("error writing page text: %v", err)
+	}
+	return filename, nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rivo/tview"
+	"golang.org/x/net/html"
+)
+
+// consentMarkers are id/class fragments used by common consent-management
+// tools and hand-written cookie banners.
+var consentMarkers = []string{"cookie", "consent", "gdpr", "cmp", "onetrust", "didomi", "usercentrics", "truste"}
+
+// maxConsentText keeps the detector conservative: an element with more
+// text than this is real content even if its class mentions cookies.
+const maxConsentText = 4000
+
+// isConsentBanner reports whether n looks like a cookie consent banner. It
+// needs both a consent marker in the element's identifying attributes and
+// consent wording in its text, and it never matches large blocks.
+func isConsentBanner(n *html.Node) bool {
+	if n.Type != html.ElementNode || !config.HideConsentBanners {
+		return false
+	}
+	switch n.Data {
+	case "div", "section", "aside", "form", "dialog", "footer":
+	default:
+		return false
+	}
+
+	identity := strings.ToLower(getAttr(n, "id") + " " + getAttr(n, "class") + " " + getAttr(n, "aria-label"))
+	marked := false
+	for _, marker := range consentMarkers {
+		if strings.Contains(identity, marker) {
+			marked = true
+			break
+		}
+	}
+	if !marked {
+		return false
+	}
+
+	text := strings.ToLower(nodeText(n))
+	if len(text) > maxConsentText {
+		return false
+	}
+	return strings.Contains(text, "cookie") || strings.Contains(text, "consent")
+}
+
+// dialogLabel names a <dialog> by its aria-label, or failing that its
+// first heading.
+func dialogLabel(n *html.Node) string {
+	if label := strings.TrimSpace(getAttr(n, "aria-label")); label != "" {
+		return label
+	}
+	var heading string
+	var walk func(*html.Node)
+	walk = func(c *html.Node) {
+		if heading != "" {
+			return
+		}
+		if headingLevel(c) > 0 {
+			heading = nodeText(c)
+			return
+		}
+		for child := c.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return heading
+}
+
+// dialogMarker is the line shown above a <dialog>'s content, or in its
+// place while the dialog is collapsed.
+func dialogMarker(n *html.Node) string {
+	marker := "[dialog]"
+	if label := dialogLabel(n); label != "" {
+		marker += " " + label
+	}
+	return tview.Escape(marker)
+}
+
+// isOpenDialog reports whether n is a <dialog> shown by default, i.e.
+// one carrying the open attribute.
+func isOpenDialog(n *html.Node) bool {
+	for _, attr := range n.Attr {
+		if attr.Key == "open" {
+			return true
+		}
+	}
+	return false
+}
+
+// collapsedPlaceholder is the single line shown in place of a collapsed
+// block.
+func collapsedPlaceholder(label string) string {
+	return fmt.Sprintf("%s▸ %s hidden (press x to expand)[-::-]\n", activeTheme.muted, label)
+}
+
+// toggleCollapsed expands or collapses all collapsible blocks in the
+// current view by rendering it again, appended next pages included. The
+// setting only lasts until another page is shown.
+func (b *browser) toggleCollapsed() {
+	if b.source == "" {
+		return
+	}
+
+	opts := renderOptions{expand: !b.expanded}
+	page, err := renderHTMLWith(b.source, b.currentURL, opts)
+	if err != nil {
+		b.setStatus(err.Error())
+		return
+	}
+	page.Status, page.Header = b.responseStatus, b.responseHeader
+	page = b.quietPage(b.currentURL, page)
+	for _, next := range b.appended {
+		rendered, err := renderHTMLWith(next.page.Source, next.base, opts)
+		if err != nil {
+			b.setStatus(err.Error())
+			return
+		}
+		page = appendPage(page, rendered, next.url)
+	}
+
+	b.expanded = opts.expand
+	row, col := b.textView.GetScrollOffset()
+	b.setPage(page)
+	b.textView.ScrollTo(row, col)
+	if b.inlineImages {
+		b.renderInlineImages()
+	}
+
+	if b.expanded {
+		b.setStatus("Showing collapsed blocks")
+	} else {
+		b.setStatus(fmt.Sprintf("Collapsed %d blocks", page.Collapsed))
+	}
+}
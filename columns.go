@@ -0,0 +1,159 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// minColumnWidth is the narrowest a column may get before the two-column
+// layout falls back to a single column.
+const minColumnWidth = 50
+
+// columnLayout flows the rendered text into two side-by-side columns, like
+// a newspaper. The text is word-wrapped to the column width and split into
+// column-height chunks so the right column continues where the left ends.
+type columnLayout struct {
+	flex        *tview.Flex
+	left, right *tview.TextView
+
+	text   string
+	lines  []string
+	offset int
+
+	width, height, columns int
+}
+
+func newColumnLayout() *columnLayout {
+	c := &columnLayout{
+		left:  tview.NewTextView().SetDynamicColors(true).SetScrollable(false),
+		right: tview.NewTextView().SetDynamicColors(true).SetScrollable(false),
+	}
+	c.flex = tview.NewFlex().
+		AddItem(c.left, 0, 1, true).
+		AddItem(tview.NewBox(), 3, 0, false).
+		AddItem(c.right, 0, 1, false)
+	c.flex.SetDrawFunc(func(screen tcell.Screen, x, y, width, height int) (int, int, int, int) {
+		c.layout(width, height)
+		return x, y, width, height
+	})
+	return c
+}
+
+func (c *columnLayout) setText(text string) {
+	c.text = text
+	c.offset = 0
+	c.width = 0
+	c.lines = nil
+}
+
+// layout re-wraps the text whenever the available size changes and fills
+// both columns from the current offset.
+func (c *columnLayout) layout(width, height int) {
+	if width != c.width || height != c.height || c.lines == nil {
+		c.width, c.height = width, height
+		c.columns = 2
+		if width < 2*minColumnWidth+3 {
+			c.columns = 1
+		}
+
+		columnWidth := width
+		if c.columns == 2 {
+			columnWidth = (width - 3) / 2
+			c.flex.ResizeItem(c.right, 0, 1)
+		} else {
+			c.flex.ResizeItem(c.right, 0, 0)
+		}
+
+		c.lines = c.lines[:0]
+		for _, line := range strings.Split(c.text, "\n") {
+			wrapped := tview.WordWrap(line, columnWidth)
+			if len(wrapped) == 0 {
+				wrapped = []string{""}
+			}
+			c.lines = append(c.lines, wrapped...)
+		}
+	}
+	c.scrollTo(c.offset)
+}
+
+func (c *columnLayout) scrollTo(offset int) {
+	if offset > len(c.lines)-c.height {
+		offset = len(c.lines) - c.height
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	c.offset = offset
+
+	c.left.SetText(strings.Join(c.chunk(offset), "\n"))
+	c.right.SetText(strings.Join(c.chunk(offset+c.height), "\n"))
+}
+
+func (c *columnLayout) chunk(start int) []string {
+	if start >= len(c.lines) {
+		return nil
+	}
+	end := start + c.height
+	if end > len(c.lines) {
+		end = len(c.lines)
+	}
+	return c.lines[start:end]
+}
+
+// handleKey scrolls by lines with j/k and by whole spreads with space,
+// PgDn, b and PgUp.
+func (c *columnLayout) handleKey(event *tcell.EventKey) *tcell.EventKey {
+	spread := c.height * c.columns
+	switch event.Key() {
+	case tcell.KeyDown:
+		c.scrollTo(c.offset + 1)
+	case tcell.KeyUp:
+		c.scrollTo(c.offset - 1)
+	case tcell.KeyPgDn:
+		c.scrollTo(c.offset + spread)
+	case tcell.KeyPgUp:
+		c.scrollTo(c.offset - spread)
+	case tcell.KeyHome:
+		c.scrollTo(0)
+	case tcell.KeyEnd:
+		c.scrollTo(len(c.lines))
+	case tcell.KeyRune:
+		switch event.Rune() {
+		case 'j':
+			c.scrollTo(c.offset + 1)
+		case 'k':
+			c.scrollTo(c.offset - 1)
+		case ' ':
+			c.scrollTo(c.offset + spread)
+		case 'b':
+			c.scrollTo(c.offset - spread)
+		default:
+			return event
+		}
+	default:
+		return event
+	}
+	return nil
+}
+
+// toggleColumns switches between the normal text view and the two-column
+// reading layout.
+func (b *browser) toggleColumns() {
+	if name, _ := b.content.GetFrontPage(); name == "columns" {
+		b.content.SwitchToPage("text")
+		b.app.SetFocus(b.textView)
+		return
+	}
+
+	_, _, width, _ := b.textView.GetInnerRect()
+	if width < 2*minColumnWidth+3 {
+		b.setStatus("Terminal too narrow for two columns")
+		return
+	}
+
+	b.columns.setText(b.textView.GetText(false))
+	b.content.SwitchToPage("columns")
+	b.app.SetFocus(b.columns.left)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type command struct {
+	usage string
+	run   func(b *browser, args string)
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"graph": {
+			usage: "graph [dot|json] [FILE]",
+			run:   (*browser).graphCommand,
+		},
+		"images": {
+			usage: "images [FILE]",
+			run:   (*browser).imagesCommand,
+		},
+		"links": {
+			usage: "links [FILE]",
+			run:   (*browser).linksCommand,
+		},
+		"request": {
+			usage: "request METHOD URL",
+			run:   (*browser).requestCommand,
+		},
+		"snippet": {
+			usage: "snippet [NAME]",
+			run:   (*browser).snippetCommand,
+		},
+	}
+}
+
+func (b *browser) openCommandLine() {
+	b.prompt(":", "", b.runCommand)
+	b.enableOmniboxAutocomplete()
+}
+
+func (b *browser) runCommand(input string) {
+	name, args, _ := strings.Cut(strings.TrimSpace(input), " ")
+	if name == "" {
+		return
+	}
+
+	cmd, ok := commands[name]
+	if !ok && looksLikeURL(input) {
+		b.openURL(normalizeURL(input))
+		return
+	}
+	if !ok {
+		b.setStatus(fmt.Sprintf("Unknown command %q (available: %s)", name, strings.Join(commandNames(), ", ")))
+		return
+	}
+	cmd.run(b, strings.TrimSpace(args))
+}
+
+func commandNames() []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
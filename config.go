@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Config is read from config.json in the user's config directory. Every
+// field is optional; missing values keep their defaults.
+type Config struct {
+	// Snippets maps a name to a URL template applied to the current page,
+	// see expandSnippet for the supported placeholders.
+	Snippets map[string]string `json:"snippets"`
+
+	// ArchiveFallback retries pages that are gone or unreachable through
+	// ArchiveTemplate, which takes the same placeholders as snippets.
+	ArchiveFallback bool   `json:"archive_fallback"`
+	ArchiveTemplate string `json:"archive_template"`
+
+	// ScrollMode is "line" for instant scrolling or "smooth" to animate
+	// ScrollStep lines per key press, one line every ScrollInterval
+	// milliseconds. Holding a key multiplies the distance by
+	// ScrollAcceleration per repeat, up to ScrollMaxStep times.
+	ScrollMode         string  `json:"scroll_mode"`
+	ScrollStep         int     `json:"scroll_step"`
+	ScrollInterval     int     `json:"scroll_interval_ms"`
+	ScrollAcceleration float64 `json:"scroll_acceleration"`
+	ScrollMaxStep      int     `json:"scroll_max_step"`
+
+	// HideConsentBanners collapses likely cookie consent banners into a
+	// single expandable line.
+	HideConsentBanners bool `json:"hide_consent_banners"`
+
+	// Whitespace is "lines" to put every text node on its own line or
+	// "html" to collapse whitespace and flow inline elements together as
+	// a browser would.
+	Whitespace string `json:"whitespace"`
+
+	// TruncateLines limits how many lines of a page are displayed until
+	// the rest is requested. Zero shows every page in full.
+	TruncateLines int `json:"truncate_lines"`
+
+	// EmojiShortcodes replaces :name: shortcodes in page text with emoji.
+	EmojiShortcodes bool `json:"emoji_shortcodes"`
+
+	// ReadingProgress shows how far the page has been read in the corner
+	// of the view.
+	ReadingProgress bool `json:"reading_progress"`
+
+	// AllowTags, when not empty, limits rendering to these elements and
+	// their contents. DenyTags are dropped along with their contents.
+	AllowTags []string `json:"allow_tags"`
+	DenyTags  []string `json:"deny_tags"`
+
+	// MaxLinks and MaxImages cap how many links and images are collected
+	// from a page. Zero means no limit.
+	MaxLinks  int `json:"max_links"`
+	MaxImages int `json:"max_images"`
+
+	// QuietMode hides lines that repeat across recently visited pages of
+	// the same site, such as headers, navigation and footers.
+	QuietMode bool `json:"quiet_mode"`
+
+	// NumberLinks shows each link's number before it so it can be followed
+	// by typing the number.
+	NumberLinks bool `json:"number_links"`
+
+	// FindCaseSensitive makes in-page search match case exactly.
+	FindCaseSensitive bool `json:"find_case_sensitive"`
+
+	// ColorImages tints ASCII art images with the colors of the image.
+	ColorImages bool `json:"color_images"`
+}
+
+var config = defaultConfig()
+
+func defaultConfig() Config {
+	return Config{
+		Snippets: map[string]string{
+			"archive": "https://web.archive.org/web/{url}",
+		},
+		ArchiveTemplate:    "https://web.archive.org/web/2/{url}",
+		ScrollMode:         "line",
+		ScrollStep:         3,
+		ScrollInterval:     16,
+		ScrollAcceleration: 1.5,
+		ScrollMaxStep:      10,
+		HideConsentBanners: true,
+		Whitespace:         "lines",
+		TruncateLines:      10000,
+		ReadingProgress:    true,
+		MaxLinks:           10000,
+		MaxImages:          2000,
+		NumberLinks:        true,
+	}
+}
+
+func configDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("error finding config dir: %v", err)
+	}
+	return filepath.Join(dir, "just-browsing"), nil
+}
+
+func loadConfig() (Config, error) {
+	cfg := defaultConfig()
+
+	dir, err := configDir()
+	if err != nil {
+		return cfg, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("error reading config: %v", err)
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return defaultConfig(), fmt.Errorf("error parsing config: %v", err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+func isDataURI(rawURL string) bool {
+	return len(rawURL) > 5 && strings.EqualFold(rawURL[:5], "data:")
+}
+
+// parseDataURI splits a data: URI into its media type and decoded payload.
+// The media type defaults to text/plain as in RFC 2397.
+func parseDataURI(rawURL string) (string, []byte, error) {
+	header, payload, ok := strings.Cut(rawURL[5:], ",")
+	if !ok {
+		return "", nil, fmt.Errorf("malformed data URI")
+	}
+
+	mediaType := "text/plain"
+	isBase64 := false
+	for i, param := range strings.Split(header, ";") {
+		switch {
+		case i == 0 && param != "":
+			mediaType = strings.ToLower(strings.TrimSpace(param))
+		case strings.EqualFold(param, "base64"):
+			isBase64 = true
+		}
+	}
+
+	if !isBase64 {
+		data, err := url.PathUnescape(payload)
+		if err != nil {
+			return "", nil, fmt.Errorf("error decoding data URI: %v", err)
+		}
+		return mediaType, []byte(data), nil
+	}
+
+	// Pages often wrap long payloads and some omit the padding
+	payload = strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\n' || r == '\r' || r == '\t' {
+			return -1
+		}
+		return r
+	}, payload)
+	if unescaped, err := url.PathUnescape(payload); err == nil {
+		payload = unescaped
+	}
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		data, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(payload, "="))
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("error decoding data URI: %v", err)
+	}
+	return mediaType, data, nil
+}
+
+// saveDataURI writes the payload of a data: URI into downloadDir so it
+// can go through the same decoding path as downloaded images.
+func saveDataURI(rawURL string) (string, error) {
+	mediaType, data, err := parseDataURI(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if int64(len(data)) > maxDownloadSize {
+		return "", fmt.Errorf("image is larger than the %s download limit", formatBytes(maxDownloadSize))
+	}
+
+	filename := generateUniqueFilename(extensionForType(mediaType, ".img"))
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		return "", fmt.Errorf("error saving image: %v", err)
+	}
+	return filename, nil
+}
+
+// shortURL abbreviates data: URIs, which can be kilobytes long, for
+// display.
+func shortURL(rawURL string) string {
+	if !isDataURI(rawURL) {
+		return rawURL
+	}
+	header, _, _ := strings.Cut(rawURL, ",")
+	return header + ",…"
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// halfDarkPNG returns a base64 data URI of a PNG whose left half is black
+// and right half white.
+func halfDarkPNG(t *testing.T) string {
+	t.Helper()
+	img := image.NewGray(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if x >= 4 {
+				img.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestBase64PNGDataURIRenders(t *testing.T) {
+	defer func(dir string) { downloadDir = dir }(downloadDir)
+	downloadDir = t.TempDir()
+
+	src := halfDarkPNG(t)
+	page := renderPage(t, "html", `<p>Logo: <img src="`+src+`" alt="logo"></p>`)
+	if len(page.Images) != 1 || page.Images[0].Src != src {
+		t.Fatalf("images = %+v, want the data URI kept as the source", page.Images)
+	}
+
+	filename, err := downloadImage(page.Images[0].Src, "https://example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Ext(filename) != ".png" || filepath.Dir(filename) != downloadDir {
+		t.Errorf("saved as %s, want a .png in the download dir", filename)
+	}
+
+	art, err := imageToASCII(filename, 8, asciiChars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := strings.Split(strings.TrimRight(art, "\n"), "\n")
+	if len(rows) == 0 || len([]rune(rows[0])) != 8 {
+		t.Fatalf("ASCII art = %q, want rows 8 characters wide", art)
+	}
+	row := []rune(rows[0])
+	if row[0] == row[7] {
+		t.Errorf("dark and bright halves drew the same character: %q", rows[0])
+	}
+}
+
+func TestParseDataURI(t *testing.T) {
+	tests := []struct {
+		uri, mediaType, data string
+	}{
+		{"data:image/png;base64,aGk=", "image/png", "hi"},
+		{"data:IMAGE/GIF;base64,aG\nk", "image/gif", "hi"},
+		{"data:,a%20b", "text/plain", "a b"},
+	}
+	for _, tt := range tests {
+		mediaType, data, err := parseDataURI(tt.uri)
+		if err != nil || mediaType != tt.mediaType || string(data) != tt.data {
+			t.Errorf("parseDataURI(%q) = %q, %q, %v", tt.uri, mediaType, data, err)
+		}
+	}
+	if _, _, err := parseDataURI("data:image/png;base64"); err == nil {
+		t.Error("a data URI without a payload was accepted")
+	}
+}
+
+func TestExportImageKeepsACopyOutsideTheDownloadDir(t *testing.T) {
+	defer func(downloads, exports string) { downloadDir, exportDir = downloads, exports }(downloadDir, exportDir)
+	downloadDir = t.TempDir()
+	exportDir = filepath.Join(t.TempDir(), "exports")
+
+	filename, err := exportImage(halfDarkPNG(t), "https://example.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(filename) != exportDir || filepath.Ext(filename) != ".png" {
+		t.Errorf("exported to %s, want a .png in %s", filename, exportDir)
+	}
+	if err := os.RemoveAll(downloadDir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filename); err != nil {
+		t.Errorf("exported image gone with the download dir: %v", err)
+	}
+}
+
+func TestExportImageKeepsEarlierSessionsExports(t *testing.T) {
+	defer func(downloads, exports string) { downloadDir, exportDir = downloads, exports }(downloadDir, exportDir)
+	exportDir = t.TempDir()
+	png, err := base64.StdEncoding.DecodeString(strings.SplitN(halfDarkPNG(t), ",", 2)[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	}))
+	defer server.Close()
+
+	var exported []string
+	for session := 0; session < 2; session++ {
+		// Each session starts with an empty download dir
+		downloadDir = t.TempDir()
+		forgetImageFiles()
+		filename, err := exportImage(server.URL+"/logo.png", server.URL+"/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		exported = append(exported, filename)
+	}
+	want := []string{filepath.Join(exportDir, "logo.png"), filepath.Join(exportDir, "logo_1.png")}
+	if !reflect.DeepEqual(exported, want) {
+		t.Errorf("exported to %v, want %v", exported, want)
+	}
+}
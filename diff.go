@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// maxDiffEdits caps the edit distance diffLines searches, since its trace
+// grows with the square of it; pages further apart are shown as replaced.
+const maxDiffEdits = 1000
+
+type diffLine struct {
+	Kind byte // ' ' unchanged, '+' added, '-' removed
+	Text string
+}
+
+// diffLines computes a line-level diff of a and b using Myers' algorithm,
+// which stays fast for large pages with few changes. Beyond maxDiffEdits
+// changes every line of a is removed and every line of b added.
+func diffLines(a, b []string) []diffLine {
+	n, m := len(a), len(b)
+	maxD := min(n+m, maxDiffEdits)
+	offset := maxD + 1
+	v := make([]int, 2*maxD+3)
+	var trace [][]int
+
+	for d := 0; d <= maxD; d++ {
+		// Only diagonals -d-1..d+1 are read when backtracking step d
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrackDiff(a, b, trace, d)
+			}
+		}
+	}
+	return replacedDiff(a, b)
+}
+
+// replacedDiff is the diff that removes all of a and adds all of b.
+func replacedDiff(a, b []string) []diffLine {
+	lines := make([]diffLine, 0, len(a)+len(b))
+	for _, line := range a {
+		lines = append(lines, diffLine{Kind: '-', Text: line})
+	}
+	for _, line := range b {
+		lines = append(lines, diffLine{Kind: '+', Text: line})
+	}
+	return lines
+}
+
+func backtrackDiff(a, b []string, trace [][]int, d int) []diffLine {
+	var lines []diffLine
+	x, y := len(a), len(b)
+	for ; d > 0; d-- {
+		v, offset := trace[d], d+1
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			lines = append(lines, diffLine{Kind: ' ', Text: a[x]})
+		}
+		if x == prevX {
+			y--
+			lines = append(lines, diffLine{Kind: '+', Text: b[y]})
+		} else {
+			x--
+			lines = append(lines, diffLine{Kind: '-', Text: a[x]})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		lines = append(lines, diffLine{Kind: ' ', Text: a[x]})
+	}
+
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return lines
+}
+
+// formatDiff renders diff lines with the theme's added and removed styles
+// and counts each kind.
+func formatDiff(lines []diffLine) (string, int, int) {
+	var out strings.Builder
+	added, removed := 0, 0
+	for _, line := range lines {
+		text := tview.Escape(line.Text)
+		switch line.Kind {
+		case '+':
+			added++
+			out.WriteString(activeTheme.added + "+ " + text + "[-::-]\n")
+		case '-':
+			removed++
+			out.WriteString(activeTheme.removed + "- " + text + "[-::-]\n")
+		default:
+			out.WriteString("  " + text + "\n")
+		}
+	}
+	return out.String(), added, removed
+}
+
+// diffWithCached fetches the current page again and shows what changed
+// since the cached copy in a separate view. The fresh copy replaces the
+// cached one so the next diff starts from it.
+func (b *browser) diffWithCached() {
+	targetURL := b.currentURL
+	cached, ok := getCachedPage(targetURL)
+	if !ok {
+		b.setStatus("No cached copy of this page to compare against")
+		return
+	}
+
+	b.setStatus("Fetching fresh copy…")
+	go func() {
+		fresh, err := fetchAndRender(targetURL)
+		if err != nil {
+			b.app.QueueUpdateDraw(func() { b.setStatus(err.Error()) })
+			return
+		}
+		putCachedPage(targetURL, fresh)
+
+		lines := diffLines(
+			strings.Split(stripTags(stripLinkMarkers(cached.Text)), "\n"),
+			strings.Split(stripTags(stripLinkMarkers(fresh.Text)), "\n"))
+		text, added, removed := formatDiff(lines)
+
+		b.app.QueueUpdateDraw(func() {
+			if added == 0 && removed == 0 {
+				b.setStatus("No changes since the cached copy")
+				return
+			}
+			b.showDiff(text, added, removed)
+		})
+	}()
+}
+
+func (b *browser) showDiff(text string, added, removed int) {
+	view := tview.NewTextView().SetDynamicColors(true).SetText(text)
+	view.SetBorder(true).SetTitle(fmt.Sprintf(" diff: +%d -%d (q to return) ", added, removed))
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape || event.Rune() == 'q' {
+			b.closeOverlay("diff")
+			return nil
+		}
+		return event
+	})
+	b.showOverlay("diff", view)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	a := []string{"title", "kept", "old", "end"}
+	b := []string{"title", "kept", "new", "end", "added"}
+	var got []string
+	for _, line := range diffLines(a, b) {
+		got = append(got, string(line.Kind)+line.Text)
+	}
+	want := " title, kept,-old,+new, end,+added"
+	if strings.Join(got, ",") != want {
+		t.Errorf("diffLines = %q, want %q", strings.Join(got, ","), want)
+	}
+}
+
+func TestDiffLinesFallsBackToReplacedPage(t *testing.T) {
+	var a, b []string
+	for i := 0; i < maxDiffEdits; i++ {
+		a = append(a, fmt.Sprintf("old %d", i))
+		b = append(b, fmt.Sprintf("new %d", i))
+	}
+	lines := diffLines(a, b)
+	if len(lines) != len(a)+len(b) {
+		t.Fatalf("diffLines returned %d lines, want %d", len(lines), len(a)+len(b))
+	}
+	if lines[0] != (diffLine{Kind: '-', Text: "old 0"}) || lines[len(a)] != (diffLine{Kind: '+', Text: "new 0"}) {
+		t.Errorf("diffLines = %v…, want all of a removed then all of b added", lines[:2])
+	}
+}
+
+func TestDiffIgnoresLinkNumbers(t *testing.T) {
+	numberLinks := config.NumberLinks
+	config.NumberLinks = true
+	defer func() { config.NumberLinks = numberLinks }()
+
+	cached := renderPage(t, "html", `<p><a href="/a">A</a></p><p>Read <a href="/b">B</a></p>`)
+	fresh := renderPage(t, "html", `<p><a href="/new">New</a> <a href="/a">A</a></p><p>Read <a href="/b">B</a></p>`)
+	lines := diffLines(
+		strings.Split(stripTags(stripLinkMarkers(cached.Text)), "\n"),
+		strings.Split(stripTags(stripLinkMarkers(fresh.Text)), "\n"))
+	_, added, removed := formatDiff(lines)
+	if added != 1 || removed != 1 {
+		t.Errorf("added %d, removed %d lines; want only the first paragraph changed: %v", added, removed, lines)
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/rivo/tview"
+)
+
+// downloadSlots bounds how many downloads run at once across the whole
+// session, and maxDownloadSize caps the bytes saved for any single file.
+var (
+	downloadSlots         = make(chan struct{}, 4)
+	maxDownloadSize int64 = 50 << 20
+)
+
+func setDownloadLimits(concurrency int, maxSize int64) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	downloadSlots = make(chan struct{}, concurrency)
+	maxDownloadSize = maxSize
+}
+
+func acquireDownloadSlot() func() {
+	downloadSlots <- struct{}{}
+	return func() { <-downloadSlots }
+}
+
+// imageFiles maps image URLs to the file they were downloaded to, so an
+// image used repeatedly is only fetched once per session.
+var (
+	imageFilesMu sync.Mutex
+	imageFiles   = make(map[string]string)
+)
+
+// cachedImageFile returns the file imageURL was downloaded to, as long as
+// it still exists.
+func cachedImageFile(imageURL string) (string, bool) {
+	imageFilesMu.Lock()
+	defer imageFilesMu.Unlock()
+
+	filename, ok := imageFiles[imageURL]
+	if !ok {
+		return "", false
+	}
+	if _, err := os.Stat(filename); err != nil {
+		delete(imageFiles, imageURL)
+		return "", false
+	}
+	return filename, true
+}
+
+func rememberImageFile(imageURL, filename string) {
+	imageFilesMu.Lock()
+	defer imageFilesMu.Unlock()
+
+	imageFiles[imageURL] = filename
+}
+
+func forgetImageFiles() {
+	imageFilesMu.Lock()
+	defer imageFilesMu.Unlock()
+
+	imageFiles = make(map[string]string)
+}
+
+// prefetchImages downloads the images of the page at pageURL with at most
+// concurrency workers and returns the files they were saved to by URL.
+// done, when not nil, is called from a worker as each image completes.
+// Once ctx is cancelled no further downloads are started.
+func prefetchImages(ctx context.Context, pageURL string, images []ImageInfo, concurrency int, done func(index int, filename string)) map[string]string {
+	var mu sync.Mutex
+	files := make(map[string]string)
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < max(concurrency, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				filename, err := fetchDecodableImage(images[i], pageURL, nil)
+				if err != nil || ctx.Err() != nil {
+					continue
+				}
+				mu.Lock()
+				files[images[i].Src] = filename
+				mu.Unlock()
+				if done != nil {
+					done(i, filename)
+				}
+			}
+		}()
+	}
+
+feed:
+	for i := range images {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+	return files
+}
+
+// createDownloadFile creates a file in downloadDir for a response, named
+// after the Content-Disposition filename, then the last segment of the URL
+// path, and finally a generated name. The extension falls back to one
+// derived from the Content-Type. Existing files are never overwritten.
+func createDownloadFile(resp *http.Response, rawURL, fallbackExt string) (*os.File, error) {
+	var name string
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		name = cleanFilename(params["filename"])
+	}
+	if name == "" {
+		if parsedURL, err := url.Parse(rawURL); err == nil {
+			name = cleanFilename(path.Base(parsedURL.Path))
+		}
+	}
+
+	ext := filepath.Ext(name)
+	if ext == "" {
+		ext = extensionForType(resp.Header.Get("Content-Type"), fallbackExt)
+	}
+	if name == "" {
+		name = filepath.Base(generateUniqueFilename(ext))
+	}
+	return createUniqueFile(downloadDir, strings.TrimSuffix(name, filepath.Ext(name)), ext)
+}
+
+// createUniqueFile creates base+ext in dir, or base_1+ext, base_2+ext and
+// so on when that exists. Names are claimed with O_EXCL so concurrent
+// downloads never pick the same one.
+func createUniqueFile(dir, base, ext string) (*os.File, error) {
+	candidate := filepath.Join(dir, base+ext)
+	for i := 1; ; i++ {
+		file, err := os.OpenFile(candidate, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if !os.IsExist(err) {
+			if err != nil {
+				return nil, fmt.Errorf("error creating file: %v", err)
+			}
+			return file, nil
+		}
+		candidate = filepath.Join(dir, fmt.Sprintf("%s_%d%s", base, i, ext))
+	}
+}
+
+// cleanFilename strips any directory components so a server-supplied name
+// cannot escape downloadDir.
+func cleanFilename(name string) string {
+	name = filepath.Base(filepath.FromSlash(name))
+	if name == "." || name == ".." || name == string(filepath.Separator) {
+		return ""
+	}
+	return name
+}
+
+func extensionForType(contentType, fallback string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fallback
+	}
+
+	switch mediaType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/svg+xml":
+		return ".svg"
+	case "text/html":
+		return ".html"
+	case "text/plain":
+		return ".txt"
+	}
+	if exts, err := mime.ExtensionsByType(mediaType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return fallback
+}
+
+// downloadFile saves whatever fileURL points to into downloadDir, named
+// after the Content-Disposition filename or the URL path, and returns the
+// path it was saved to. progress, when not nil, is told how far it got.
+func downloadFile(fileURL string, progress progressFunc) (string, error) {
+	if isDataURI(fileURL) {
+		return saveDataURI(fileURL)
+	}
+
+	release := acquireDownloadSlot()
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), navigationTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fileURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %v", err)
+	}
+	setBasicAuth(req)
+
+	resp, err := httpClient.Do(req)
+	if isTimeout(err) {
+		return "", errTimeout
+	}
+	if err != nil {
+		return "", fmt.Errorf("error downloading file: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("bad status: %s", resp.Status)
+	}
+	if resp.ContentLength > maxDownloadSize {
+		return "", fmt.Errorf("file is larger than the %s download limit", formatBytes(maxDownloadSize))
+	}
+
+	out, err := createDownloadFile(resp, fileURL, "")
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+	filename := out.Name()
+
+	written, err := io.Copy(out, newProgressReader(io.LimitReader(resp.Body, maxDownloadSize+1), resp.ContentLength, progress))
+	if err == nil && written > maxDownloadSize {
+		err = fmt.Errorf("file is larger than the %s download limit", formatBytes(maxDownloadSize))
+	} else if isTimeout(err) {
+		err = errTimeout
+	} else if err != nil {
+		err = fmt.Errorf("error saving file: %v", err)
+	}
+	if err != nil {
+		out.Close()
+		os.Remove(filename)
+		return "", err
+	}
+	return filename, nil
+}
+
+// downloadLink saves the target of the selected link instead of following
+// it.
+func (b *browser) downloadLink() {
+	link, ok := b.currentLink()
+	if !ok {
+		b.setStatus("No link selected (press Tab to select one)")
+		return
+	}
+	if isLocalURL(link.Href) {
+		b.setStatus("Local files are already on disk")
+		return
+	}
+	if message, ok := checkNavigable(link.Href); !ok && !isDataURI(link.Href) {
+		b.setStatus(message)
+		return
+	}
+
+	label := "Downloading " + tview.Escape(displayURL(link.Href)) + "…"
+	b.setStatus(label)
+	go func() {
+		filename, err := downloadFile(link.Href, b.statusProgress(label))
+		b.app.QueueUpdateDraw(func() {
+			if err != nil {
+				b.setStatus(err.Error())
+				return
+			}
+			b.setStatus("Saved " + tview.Escape(filename))
+		})
+	}()
+}
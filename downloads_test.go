@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestCreateDownloadFileNeverSharesAName(t *testing.T) {
+	defer func(dir string) { downloadDir = dir }(downloadDir)
+	downloadDir = t.TempDir()
+
+	const downloads = 20
+	names := make(chan string, downloads)
+	var wg sync.WaitGroup
+	for i := 0; i < downloads; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Same basename, different paths, as on a page's images
+			rawURL := "https://example.com/a/logo.png"
+			if i%2 == 1 {
+				rawURL = "https://example.com/b/logo.png"
+			}
+			file, err := createDownloadFile(&http.Response{Header: http.Header{}}, rawURL, ".jpg")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			file.Close()
+			names <- file.Name()
+		}(i)
+	}
+	wg.Wait()
+	close(names)
+
+	seen := make(map[string]bool)
+	for name := range names {
+		if seen[name] {
+			t.Errorf("%s was handed out twice", name)
+		}
+		seen[name] = true
+	}
+	if !seen[filepath.Join(downloadDir, "logo.png")] || !seen[filepath.Join(downloadDir, "logo_1.png")] {
+		t.Errorf("names = %v, want logo.png, logo_1.png and so on", seen)
+	}
+}
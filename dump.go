@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	colorTagPattern   = regexp.MustCompile(`\[([a-zA-Z]+|#[0-9a-zA-Z]{6}|\-)?(:([a-zA-Z]+|#[0-9a-zA-Z]{6}|\-)?(:([bdilrsu]+|\-)?)?)?\]`)
+	regionTagPattern  = regexp.MustCompile(`\["[a-zA-Z0-9_,;: \-\.]*"\]`)
+	escapedTagPattern = regexp.MustCompile(`\[([a-zA-Z0-9_,;: \-\."#]+)\[(\[*)\]`)
+
+	// markupPattern matches any of the above.
+	markupPattern = regexp.MustCompile(colorTagPattern.String() + "|" + regionTagPattern.String() + "|" + escapedTagPattern.String())
+)
+
+// stripTags removes tview color and region tags from rendered text and
+// unescapes literal brackets, leaving plain text for non-interactive output.
+// All tags are matched in one pass so an escaped "[1[]" is not mistaken
+// for text followed by an empty color tag.
+func stripTags(text string) string {
+	return markupPattern.ReplaceAllStringFunc(text, func(tag string) string {
+		if escapedTagPattern.MatchString(tag) {
+			return escapedTagPattern.ReplaceAllString(tag, "[$1$2]")
+		}
+		return ""
+	})
+}
+
+// annotatedLinkPattern matches a whole link region, capturing its number.
+var annotatedLinkPattern = regexp.MustCompile(`\["link-(\d+)"\](?s:.*?)\[""\]`)
+
+// writeAnnotated writes the page's plain text with a [N] marker after each
+// link, followed by a reference table mapping every index to the link's
+// rendered line and resolved URL. Markers go after each link's region; a
+// link without one is marked at the end of its line.
+func writeAnnotated(w io.Writer, page *Page) error {
+	marked := make(map[int]bool)
+	text := annotatedLinkPattern.ReplaceAllStringFunc(page.Text, func(region string) string {
+		index, _ := strconv.Atoi(annotatedLinkPattern.FindStringSubmatch(region)[1])
+		marked[index] = true
+		return fmt.Sprintf("%s[%d[]", region, index)
+	})
+
+	lines := strings.Split(text, "\n")
+	for _, link := range page.Links {
+		if marked[link.Index] {
+			continue
+		}
+		line := min(max(link.Line, 0), len(lines)-1)
+		lines[line] += fmt.Sprintf(" [%d[]", link.Index)
+	}
+
+	var out strings.Builder
+	out.WriteString(stripTags(strings.Join(lines, "\n")))
+	if len(page.Links) > 0 {
+		out.WriteString("\nReferences\n")
+		for _, link := range page.Links {
+			fmt.Fprintf(&out, "[%d] line %d: %s\n", link.Index, link.Line, link.Href)
+		}
+	}
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// printAnnotated fetches targetURL and writes it as writeAnnotated does to
+// the file output or to stdout.
+func printAnnotated(targetURL, output string) error {
+	// writeAnnotated adds its own markers
+	config.NumberLinks = false
+	setColorLevel(colorNone)
+	page, err := fetchAndRender(targetURL)
+	if err != nil {
+		return err
+	}
+	var out strings.Builder
+	if err := writeAnnotated(&out, page); err != nil {
+		return err
+	}
+
+	if output == "" {
+		_, err := io.WriteString(os.Stdout, out.String())
+		return err
+	}
+	if err := os.WriteFile(output, []byte(out.String()), 0644); err != nil {
+		return fmt.Errorf("error writing %s: %v", output, err)
+	}
+	return nil
+}
+
+// writeDump writes the page's plain text, with links marked by their
+// number, followed by a numbered list of the link URLs.
+func writeDump(w io.Writer, page *Page) error {
+	var out strings.Builder
+	out.WriteString(stripTags(page.Text))
+	if !strings.HasSuffix(out.String(), "\n") {
+		out.WriteString("\n")
+	}
+
+	if len(page.Links) > 0 {
+		out.WriteString("\nLinks\n")
+		for _, link := range page.Links {
+			fmt.Fprintf(&out, "[%d] %s\n", link.Index, link.Href)
+		}
+	}
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// printPage fetches targetURL and writes it in format, "text" (the
+// default) or "markdown", to the file output or to stdout.
+func printPage(targetURL, format, output string) error {
+	var content string
+	switch format {
+	case "", "text":
+		setColorLevel(colorNone)
+		page, err := fetchAndRender(targetURL)
+		if err != nil {
+			return err
+		}
+		var out strings.Builder
+		if err := writeDump(&out, page); err != nil {
+			return err
+		}
+		content = out.String()
+	case "markdown", "md":
+		htmlContent, chain, err := fetchURLRedirects(targetURL)
+		if err != nil {
+			return err
+		}
+		if content, err = renderMarkdown(htmlContent, chain[len(chain)-1]); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown format %q, expected text or markdown", format)
+	}
+
+	if output == "" {
+		_, err := io.WriteString(os.Stdout, content)
+		return err
+	}
+	if err := os.WriteFile(output, []byte(content), 0644); err != nil {
+		return fmt.Errorf("error writing %s: %v", output, err)
+	}
+	return nil
+}
+
+func fetchAndRender(targetURL string) (*Page, error) {
+	htmlContent, chain, err := fetchURLRedirects(targetURL)
+	if err != nil {
+		return nil, err
+	}
+	return renderHTML(htmlContent, chain[len(chain)-1])
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteAnnotatedMarksEachLinkWhereItIs(t *testing.T) {
+	numberLinks := config.NumberLinks
+	config.NumberLinks = false
+	defer func() { config.NumberLinks = numberLinks }()
+
+	page := renderPage(t, "html", `<p>Go home, or read about home.</p><p><a href="/home">home</a> and <a href="/about">about</a></p>`)
+	var out strings.Builder
+	if err := writeAnnotated(&out, page); err != nil {
+		t.Fatal(err)
+	}
+	want := "Go home, or read about home.\n\nhome[1] and about[2]\n\n" +
+		"\nReferences\n[1] line 2: https://example.com/home\n[2] line 2: https://example.com/about\n"
+	if out.String() != want {
+		t.Errorf("writeAnnotated = %q, want %q", out.String(), want)
+	}
+}
+
+func TestWriteAnnotatedKeepsMarkersOfLinksWithoutRegions(t *testing.T) {
+	page := &Page{
+		Text:  "First line\nSecond line\n",
+		Links: []LinkInfo{{Text: "missing", Href: "https://example.com/", Line: 1, Index: 1}},
+	}
+	var out strings.Builder
+	if err := writeAnnotated(&out, page); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out.String(), "First line\nSecond line [1]\n") {
+		t.Errorf("writeAnnotated = %q, want the marker at the end of the link's line", out.String())
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import "regexp"
+
+// shortcodePattern matches :name: emoji shortcodes. Names may not start
+// with a digit so times like 10:30:00 are left alone; :100: is the one
+// common code this misses.
+var shortcodePattern = regexp.MustCompile(`:([a-z+-][a-z0-9_+-]*):`)
+
+// emojiShortcodes covers the shortcodes most common in comments, READMEs
+// and chat exports, using GitHub's names.
+var emojiShortcodes = map[string]string{
+	"+1":                       "👍",
+	"-1":                       "👎",
+	"thumbsup":                 "👍",
+	"thumbsdown":               "👎",
+	"smile":                    "😄",
+	"smiley":                   "😃",
+	"grin":                     "😁",
+	"laughing":                 "😆",
+	"joy":                      "😂",
+	"wink":                     "😉",
+	"blush":                    "😊",
+	"heart_eyes":               "😍",
+	"thinking":                 "🤔",
+	"confused":                 "😕",
+	"cry":                      "😢",
+	"sob":                      "😭",
+	"angry":                    "😠",
+	"scream":                   "😱",
+	"sunglasses":               "😎",
+	"neutral_face":             "😐",
+	"slightly_smiling_face":    "🙂",
+	"upside_down_face":         "🙃",
+	"heart":                    "❤️",
+	"broken_heart":             "💔",
+	"star":                     "⭐",
+	"sparkles":                 "✨",
+	"fire":                     "🔥",
+	"tada":                     "🎉",
+	"rocket":                   "🚀",
+	"eyes":                     "👀",
+	"clap":                     "👏",
+	"wave":                     "👋",
+	"pray":                     "🙏",
+	"muscle":                   "💪",
+	"ok_hand":                  "👌",
+	"point_right":              "👉",
+	"raised_hands":             "🙌",
+	"warning":                  "⚠️",
+	"x":                        "❌",
+	"white_check_mark":         "✅",
+	"heavy_check_mark":         "✔️",
+	"question":                 "❓",
+	"exclamation":              "❗",
+	"bulb":                     "💡",
+	"memo":                     "📝",
+	"bug":                      "🐛",
+	"lock":                     "🔒",
+	"key":                      "🔑",
+	"link":                     "🔗",
+	"zap":                      "⚡",
+	"construction":             "🚧",
+	"coffee":                   "☕",
+	"beer":                     "🍺",
+	"pizza":                    "🍕",
+	"cake":                     "🍰",
+	"sun":                      "☀️",
+	"cloud":                    "☁️",
+	"snowflake":                "❄️",
+	"rainbow":                  "🌈",
+	"earth_americas":           "🌎",
+	"calendar":                 "📅",
+	"email":                    "📧",
+	"phone":                    "📱",
+	"computer":                 "💻",
+	"books":                    "📚",
+	"mag":                      "🔍",
+	"chart_with_upwards_trend": "📈",
+}
+
+// replaceShortcodes turns known :name: shortcodes in text into emoji and
+// leaves unknown ones as they are.
+func replaceShortcodes(text string) string {
+	return shortcodePattern.ReplaceAllStringFunc(text, func(match string) string {
+		if emoji, ok := emojiShortcodes[match[1:len(match)-1]]; ok {
+			return emoji
+		}
+		return match
+	})
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rivo/tview"
+	"github.com/rivo/uniseg"
+)
+
+func TestEmojiEntitiesRenderAsGlyphs(t *testing.T) {
+	text := stripTags(renderPage(t, "html", `<p>&#x1F680;&#128512; &#x1f44d;&#x1f3fd;</p>`).Text)
+	want := "🚀😀 👍🏽\n\n"
+	if text != want {
+		t.Fatalf("rendered %q, want %q", text, want)
+	}
+	for _, glyph := range []string{"🚀", "😀", "👍🏽"} {
+		if n := uniseg.GraphemeClusterCount(glyph); n != 1 {
+			t.Errorf("%q is %d graphemes, want 1", glyph, n)
+		}
+		if width := uniseg.StringWidth(glyph); width != 2 {
+			t.Errorf("%q is %d columns wide, want 2", glyph, width)
+		}
+	}
+}
+
+func TestEmojiWrapByWidth(t *testing.T) {
+	// Five double-width emoji need ten columns, so they wrap at nine
+	lines := tview.WordWrap(strings.Repeat("🚀", 5), 9)
+	if len(lines) != 2 || lines[0] != "🚀🚀🚀🚀" {
+		t.Errorf("WordWrap = %q, want four rockets then one", lines)
+	}
+}
+
+func TestEmojiShortcodes(t *testing.T) {
+	doc := `<p>Shipped :tada: but :not_a_code: stays</p>`
+	if text := stripTags(renderPage(t, "html", doc).Text); !strings.Contains(text, ":tada:") {
+		t.Errorf("shortcodes replaced without the flag: %q", text)
+	}
+
+	defer func(before bool) { config.EmojiShortcodes = before }(config.EmojiShortcodes)
+	config.EmojiShortcodes = true
+	if text := stripTags(renderPage(t, "html", doc).Text); text != "Shipped 🎉 but :not_a_code: stays\n\n" {
+		t.Errorf("rendered %q", text)
+	}
+}
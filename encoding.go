@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// encodingTransport asks for gzip or deflate compressed responses and
+// decodes them, so callers always read plain bodies. Unlike the standard
+// transport's built-in gzip support it also handles deflate.
+type encodingTransport struct {
+	base http.RoundTripper
+}
+
+func (t *encodingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Accept-Encoding") != "" || req.Header.Get("Range") != "" {
+		return t.base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || req.Method == http.MethodHead {
+		return resp, err
+	}
+
+	body, err := decodeBody(resp.Header.Get("Content-Encoding"), resp.Body)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if body != resp.Body {
+		resp.Body = body
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("Content-Length")
+		resp.ContentLength = -1
+		resp.Uncompressed = true
+	}
+	return resp, nil
+}
+
+// decodeBody wraps body in a decompressor for encoding. The returned
+// reader closes both the decompressor and body.
+func decodeBody(encoding string, body io.ReadCloser) (io.ReadCloser, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "gzip", "x-gzip":
+		reader, err := gzip.NewReader(body)
+		if err == io.EOF {
+			// Empty bodies, e.g. for 204 or 304, have no gzip header
+			return body, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing response: %v", err)
+		}
+		return &decodedBody{Reader: reader, closers: []io.Closer{reader, body}}, nil
+	case "deflate":
+		// "deflate" should be zlib-wrapped but some servers send raw
+		// deflate data, which is told apart by the zlib header
+		buffered := bufio.NewReader(body)
+		header, err := buffered.Peek(2)
+		if err == io.EOF || len(header) < 2 {
+			return body, nil
+		}
+		var reader io.ReadCloser
+		if (uint16(header[0])<<8|uint16(header[1]))%31 == 0 && header[0]&0x0f == 8 {
+			reader, err = zlib.NewReader(buffered)
+			if err != nil {
+				return nil, fmt.Errorf("error decompressing response: %v", err)
+			}
+		} else {
+			reader = flate.NewReader(buffered)
+		}
+		return &decodedBody{Reader: reader, closers: []io.Closer{reader, body}}, nil
+	}
+	return body, nil
+}
+
+type decodedBody struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (b *decodedBody) Close() error {
+	var first error
+	for _, c := range b.closers {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// declaresCharset reports whether body has a <meta> charset declaration
+// within the first 1024 bytes, where the HTML standard looks for one.
+func declaresCharset(body []byte) bool {
+	z := html.NewTokenizer(bytes.NewReader(body[:min(len(body), 1024)]))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return false
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if string(name) != "meta" {
+				continue
+			}
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				if string(key) == "charset" || string(key) == "content" && strings.Contains(strings.ToLower(string(val)), "charset=") {
+					return true
+				}
+			}
+		}
+	}
+}
+
+// decodeCharset converts an HTML body to UTF-8 using the charset from the
+// Content-Type header, a byte order mark or a <meta> declaration. Bodies
+// that declare nothing are taken as UTF-8 when they are valid UTF-8,
+// rather than as windows-1252 like the HTML standard's fallback.
+func decodeCharset(body []byte, contentType string) (string, error) {
+	encoding, name, certain := charset.DetermineEncoding(body, contentType)
+	if name == "utf-8" || !certain && !declaresCharset(body) && utf8.Valid(body) {
+		return string(body), nil
+	}
+
+	decoded, err := io.ReadAll(encoding.NewDecoder().Reader(bytes.NewReader(body)))
+	if err != nil {
+		return "", fmt.Errorf("error decoding %s: %v", name, err)
+	}
+	return string(decoded), nil
+}
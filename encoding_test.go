@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestDecodeCharset(t *testing.T) {
+	tests := []struct {
+		name, body, contentType, want string
+	}{
+		{"undeclared UTF-8", "<p>caf\xc3\xa9</p>", "text/html", "<p>café</p>"},
+		{"undeclared Latin-1", "<p>caf\xe9</p>", "text/html", "<p>café</p>"},
+		{"header charset", "<p>caf\xe9</p>", "text/html; charset=iso-8859-1", "<p>café</p>"},
+		// Bytes that are valid UTF-8 still follow a <meta> declaration
+		{"meta charset", `<meta charset="windows-1252"><p>caf` + "\xc3\xa9</p>", "text/html", `<meta charset="windows-1252"><p>cafÃ©</p>`},
+		{"meta http-equiv", `<meta http-equiv="Content-Type" content="text/html; charset=iso-8859-1"><p>` + "\xc3\xa9</p>", "",
+			`<meta http-equiv="Content-Type" content="text/html; charset=iso-8859-1"><p>Ã©</p>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeCharset([]byte(tt.body), tt.contentType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("decodeCharset = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
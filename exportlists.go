@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// writeLinkList writes one line per distinct link target: the resolved
+// URL, a tab and the link text of its first occurrence.
+func writeLinkList(w io.Writer, links []LinkInfo) error {
+	seen := make(map[string]bool)
+	var out strings.Builder
+	for _, link := range links {
+		if seen[link.Href] {
+			continue
+		}
+		seen[link.Href] = true
+		fmt.Fprintf(&out, "%s\t%s\n", link.Href, singleLine(link.Text))
+	}
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// writeImageList writes one line per distinct image source: the resolved
+// URL, a tab and the alt text of its first occurrence.
+func writeImageList(w io.Writer, images []ImageInfo) error {
+	seen := make(map[string]bool)
+	var out strings.Builder
+	for _, img := range images {
+		if seen[img.Src] {
+			continue
+		}
+		seen[img.Src] = true
+		fmt.Fprintf(&out, "%s\t%s\n", img.Src, singleLine(img.Alt))
+	}
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// singleLine collapses s onto one line so every list entry stays one line.
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// printLists fetches targetURL and writes its link list, image list or
+// both to the file output or to stdout.
+func printLists(targetURL string, links, images bool, output string) error {
+	setColorLevel(colorNone)
+	page, err := fetchAndRender(targetURL)
+	if err != nil {
+		return err
+	}
+
+	var out strings.Builder
+	if links {
+		writeLinkList(&out, page.Links)
+	}
+	if images {
+		writeImageList(&out, page.Images)
+	}
+
+	if output == "" {
+		_, err := io.WriteString(os.Stdout, out.String())
+		return err
+	}
+	if err := os.WriteFile(output, []byte(out.String()), 0644); err != nil {
+		return fmt.Errorf("error writing %s: %v", output, err)
+	}
+	return nil
+}
+
+// exportList writes the page's links or images, as kind says, into
+// exportDir, or into filename when it is not empty.
+func (b *browser) exportList(kind, filename string) {
+	count := len(b.links)
+	if kind == "images" {
+		count = len(b.images)
+	}
+	if count == 0 {
+		b.setStatus("No " + kind + " on this page")
+		return
+	}
+
+	if filename == "" {
+		if err := os.MkdirAll(exportDir, 0755); err != nil {
+			b.setStatus(fmt.Sprintf("Error creating export dir: %v", err))
+			return
+		}
+		filename = filepath.Join(exportDir, fmt.Sprintf("%s_%s.txt", kind, time.Now().Format("20060102_150405")))
+	}
+
+	out, err := os.Create(filename)
+	if err != nil {
+		b.setStatus(fmt.Sprintf("Error creating file: %v", err))
+		return
+	}
+	defer out.Close()
+
+	if kind == "images" {
+		err = writeImageList(out, b.images)
+	} else {
+		err = writeLinkList(out, b.links)
+	}
+	if err != nil {
+		b.setStatus(fmt.Sprintf("Error writing %s: %v", kind, err))
+		return
+	}
+	b.setStatus(fmt.Sprintf("Wrote %d %s to %s", count, kind, filename))
+}
+
+func (b *browser) linksCommand(args string) {
+	b.exportList("links", args)
+}
+
+func (b *browser) imagesCommand(args string) {
+	b.exportList("images", args)
+}
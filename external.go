@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// openExternal opens targetURL in the system's default browser without
+// waiting for it to exit.
+func openExternal(targetURL string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", targetURL)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", targetURL)
+	default:
+		cmd = exec.Command("xdg-open", targetURL)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("error opening browser: %v", err)
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// openInBrowser opens the selected link, or the current page when no link
+// is selected, in the system's default browser.
+func (b *browser) openInBrowser() {
+	target := b.currentURL
+	if link, ok := b.currentLink(); ok {
+		target = link.Href
+	}
+	if target == "" {
+		b.setStatus("No page loaded")
+		return
+	}
+
+	if err := openExternal(target); err != nil {
+		b.setStatus(err.Error())
+		return
+	}
+	b.setStatus("Opened " + displayURL(target) + " in the default browser")
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// maxSummaryLength is how many characters of an entry's summary are shown
+// in a feed listing; the full text is one link away.
+const maxSummaryLength = 300
+
+// feedDateLayouts are the date formats seen in RSS pubDate and Atom
+// updated elements, tried in order.
+var feedDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	time.RFC3339,
+}
+
+// feed is an RSS or Atom feed reduced to what a listing shows.
+type feed struct {
+	Title       string
+	Description string
+	Entries     []feedEntry
+}
+
+type feedEntry struct {
+	Title   string
+	Link    string
+	Date    string
+	Summary string
+}
+
+// rssFeed and atomFeed mirror the parts of RSS 2.0 and Atom documents
+// that are shown.
+type rssFeed struct {
+	Channel struct {
+		Title       string `xml:"title"`
+		Description string `xml:"description"`
+		Items       []struct {
+			Title       string `xml:"title"`
+			Link        string `xml:"link"`
+			GUID        string `xml:"guid"`
+			PubDate     string `xml:"pubDate"`
+			Description string `xml:"description"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+type atomFeed struct {
+	Title    string `xml:"title"`
+	Subtitle string `xml:"subtitle"`
+	Entries  []struct {
+		Title     string     `xml:"title"`
+		Links     []atomLink `xml:"link"`
+		Updated   string     `xml:"updated"`
+		Published string     `xml:"published"`
+		Summary   string     `xml:"summary"`
+		Content   string     `xml:"content"`
+	} `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+}
+
+// newFeedDecoder returns a decoder for body, which has already been
+// decoded to UTF-8 whatever its XML declaration says.
+func newFeedDecoder(body string) *xml.Decoder {
+	decoder := xml.NewDecoder(strings.NewReader(body))
+	decoder.Strict = false
+	decoder.CharsetReader = func(_ string, r io.Reader) (io.Reader, error) { return r, nil }
+	return decoder
+}
+
+// feedRoot returns the name of the root element of body when it is
+// well-formed enough to have one.
+func feedRoot(body string) string {
+	decoder := newFeedDecoder(body)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return ""
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local
+		}
+	}
+}
+
+// mayBeFeed reports whether a response of contentType is checked for a
+// feed: the RSS and Atom types, generic XML, which many feeds are served
+// as, and responses without a usable type.
+func mayBeFeed(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return true
+	}
+	switch mediaType {
+	case "application/rss+xml", "application/atom+xml", "application/xml", "text/xml":
+		return true
+	}
+	return false
+}
+
+// parseFeed parses body as an RSS 2.0 or Atom feed. It returns false when
+// body is neither.
+func parseFeed(body string) (*feed, bool) {
+	switch feedRoot(body) {
+	case "rss":
+		var doc rssFeed
+		if err := newFeedDecoder(body).Decode(&doc); err != nil {
+			return nil, false
+		}
+		f := &feed{Title: doc.Channel.Title, Description: summaryText(doc.Channel.Description)}
+		for _, item := range doc.Channel.Items {
+			link := strings.TrimSpace(item.Link)
+			if link == "" && strings.Contains(item.GUID, "://") {
+				link = strings.TrimSpace(item.GUID)
+			}
+			f.Entries = append(f.Entries, feedEntry{
+				Title:   item.Title,
+				Link:    link,
+				Date:    feedDate(item.PubDate),
+				Summary: summaryText(item.Description),
+			})
+		}
+		return f, true
+	case "feed":
+		var doc atomFeed
+		if err := newFeedDecoder(body).Decode(&doc); err != nil {
+			return nil, false
+		}
+		f := &feed{Title: doc.Title, Description: summaryText(doc.Subtitle)}
+		for _, entry := range doc.Entries {
+			date := entry.Published
+			if date == "" {
+				date = entry.Updated
+			}
+			summary := entry.Summary
+			if summary == "" {
+				summary = entry.Content
+			}
+			f.Entries = append(f.Entries, feedEntry{
+				Title:   entry.Title,
+				Link:    atomEntryLink(entry.Links),
+				Date:    feedDate(date),
+				Summary: summaryText(summary),
+			})
+		}
+		return f, true
+	}
+	return nil, false
+}
+
+// atomEntryLink picks the alternate link of an Atom entry, which is the
+// one without a rel or with rel="alternate".
+func atomEntryLink(links []atomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return strings.TrimSpace(link.Href)
+		}
+	}
+	return ""
+}
+
+// feedDate shortens a feed date to the day it names, leaving dates in
+// unknown formats as they are.
+func feedDate(date string) string {
+	date = strings.TrimSpace(date)
+	for _, layout := range feedDateLayouts {
+		if t, err := time.Parse(layout, date); err == nil {
+			return t.Format("2006-01-02")
+		}
+	}
+	return date
+}
+
+// summaryText turns a summary, which feeds often give as escaped HTML,
+// into plain text of at most maxSummaryLength characters.
+func summaryText(summary string) string {
+	doc, err := html.Parse(strings.NewReader(summary))
+	if err != nil {
+		return ""
+	}
+	text := []rune(nodeText(doc))
+	if len(text) <= maxSummaryLength {
+		return string(text)
+	}
+	cut := string(text[:maxSummaryLength])
+	if space := strings.LastIndexByte(cut, ' '); space > 0 {
+		cut = cut[:space]
+	}
+	return cut + "…"
+}
+
+// feedHTML renders f as an HTML page listing its entries, each with its
+// title linked, its date and its summary.
+func feedHTML(f *feed) string {
+	var out strings.Builder
+	title := html.EscapeString(f.Title)
+	fmt.Fprintf(&out, "<html><head><title>%s</title></head><body><h1>%s</h1>", title, title)
+	if f.Description != "" {
+		fmt.Fprintf(&out, "<p>%s</p>", html.EscapeString(f.Description))
+	}
+	if len(f.Entries) == 0 {
+		out.WriteString("<p>This feed has no entries.</p>")
+	}
+	for _, entry := range f.Entries {
+		entryTitle := html.EscapeString(strings.TrimSpace(entry.Title))
+		if entryTitle == "" {
+			entryTitle = "(untitled)"
+		}
+		if entry.Link != "" {
+			entryTitle = fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(entry.Link), entryTitle)
+		}
+		fmt.Fprintf(&out, "<h2>%s</h2>", entryTitle)
+		if entry.Date != "" {
+			fmt.Fprintf(&out, "<p><small>%s</small></p>", html.EscapeString(entry.Date))
+		}
+		if entry.Summary != "" {
+			fmt.Fprintf(&out, "<p>%s</p>", html.EscapeString(entry.Summary))
+		}
+	}
+	out.WriteString("</body></html>")
+	return out.String()
+}
+
+// feedAsHTML returns body as a page listing its entries when it is an RSS
+// or Atom feed, and unchanged otherwise.
+func feedAsHTML(body, contentType string) string {
+	if !mayBeFeed(contentType) {
+		return body
+	}
+	f, ok := parseFeed(body)
+	if !ok {
+		return body
+	}
+	return feedHTML(f)
+}
@@ -0,0 +1,23 @@
+package main
+
+import "strings"
+
+// tagAllowed reports whether tag is in the configured allowlist.
+func tagAllowed(tag string) bool {
+	return containsTag(config.AllowTags, tag)
+}
+
+// tagDenied reports whether tag is in the configured denylist, which
+// drops the element and everything inside it.
+func tagDenied(tag string) bool {
+	return containsTag(config.DenyTags, tag)
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
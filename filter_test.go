@@ -0,0 +1,40 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRestrictiveAllowlist(t *testing.T) {
+	defer func(allow, deny []string) { config.AllowTags, config.DenyTags = allow, deny }(config.AllowTags, config.DenyTags)
+	config.AllowTags = []string{"h1", "p"}
+	config.DenyTags = []string{"footer"}
+
+	doc := `<nav><a href=/home>Home</a></nav><h1>Title</h1>
+		<div>Loose text <a href=/div>div link</a></div>
+		<p>Para with <a href=/para>link</a></p>
+		<ul><li>item</li></ul>
+		<footer><p>Footer para</p></footer>`
+	page := renderPage(t, "html", doc)
+
+	if got, want := stripTags(page.Text), "Title\n\nPara with [1] link\n\n"; got != want {
+		t.Errorf("rendered %q, want %q", got, want)
+	}
+	var hrefs []string
+	for _, link := range page.Links {
+		hrefs = append(hrefs, link.Href)
+	}
+	if want := []string{"https://example.com/para"}; !reflect.DeepEqual(hrefs, want) {
+		t.Errorf("links = %q, want only those inside allowed elements: %q", hrefs, want)
+	}
+}
+
+func TestDenylistWithoutAllowlist(t *testing.T) {
+	defer func(deny []string) { config.DenyTags = deny }(config.DenyTags)
+	config.DenyTags = []string{"aside"}
+
+	page := renderPage(t, "html", `<p>Kept</p><aside><p>Dropped</p></aside><div>Also kept</div>`)
+	if got, want := stripTags(page.Text), "Kept\n\nAlso kept\n"; got != want {
+		t.Errorf("rendered %q, want %q", got, want)
+	}
+}
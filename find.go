@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// highlightMatches marks every occurrence of query in text and wraps it in
+// a find-N region, skipping over markup so tags are neither
+// searched nor split. Regions cannot nest, so a link region around a match
+// is closed before it and reopened after it. It returns the new text
+// and the number of matches.
+func highlightMatches(text, query string, caseSensitive bool) (string, int) {
+	flags := "(?i)"
+	if caseSensitive {
+		flags = ""
+	}
+	pattern, err := regexp.Compile(flags + regexp.QuoteMeta(query))
+	if err != nil || query == "" {
+		return text, 0
+	}
+
+	var out strings.Builder
+	count := 0
+	region := ""
+	highlight := func(segment string) {
+		last := 0
+		for _, match := range pattern.FindAllStringIndex(segment, -1) {
+			out.WriteString(segment[last:match[0]])
+			if region != "" {
+				out.WriteString(`[""]`)
+			}
+			fmt.Fprintf(&out, `["find-%d"]%s%s[-:-:-][""]`, count, activeTheme.match, segment[match[0]:match[1]])
+			out.WriteString(region)
+			last = match[1]
+			count++
+		}
+		out.WriteString(segment[last:])
+	}
+
+	last := 0
+	for _, tag := range markupPattern.FindAllStringIndex(text, -1) {
+		highlight(text[last:tag[0]])
+		out.WriteString(text[tag[0]:tag[1]])
+		if markup := text[tag[0]:tag[1]]; regionTagPattern.MatchString(markup) && !escapedTagPattern.MatchString(markup) {
+			region = markup
+			if region == `[""]` {
+				region = ""
+			}
+		}
+		last = tag[1]
+	}
+	highlight(text[last:])
+	return out.String(), count
+}
+
+// openFind searches the page as the query is typed, scrolling to the
+// first match. Enter keeps the matches for n and N; Escape clears them and
+// returns to where the search started.
+func (b *browser) openFind() {
+	row, col := b.textView.GetScrollOffset()
+
+	b.prompt("/", b.findQuery, func(string) {})
+	b.promptField.SetChangedFunc(func(text string) {
+		b.find(text)
+	})
+	b.promptField.SetDoneFunc(func(key tcell.Key) {
+		b.closePrompt()
+		if key == tcell.KeyEscape {
+			b.find("")
+			b.textView.ScrollTo(row, col)
+		}
+	})
+}
+
+// find highlights query on the displayed page and jumps to its first
+// match. An empty query clears the highlights.
+func (b *browser) find(query string) {
+	b.findQuery = query
+	b.findIndex = 0
+	if query == "" {
+		b.findCount = 0
+		b.textView.SetText(b.shown)
+		b.setStatus("")
+		return
+	}
+
+	highlighted, count := highlightMatches(b.shown, query, config.FindCaseSensitive)
+	b.findCount = count
+	b.textView.SetText(highlighted)
+	if count == 0 {
+		b.textView.Highlight()
+		b.setStatus(fmt.Sprintf("%sNo matches for %q[-::-]", activeTheme.errorTag, query))
+		return
+	}
+	b.showMatch()
+}
+
+// cycleMatch moves to the next or, with a negative step, previous match of
+// the last search.
+func (b *browser) cycleMatch(step int) {
+	if b.findCount == 0 {
+		b.setStatus("No search results")
+		return
+	}
+	b.findIndex = (b.findIndex + step + b.findCount) % b.findCount
+	b.showMatch()
+}
+
+func (b *browser) showMatch() {
+	b.textView.Highlight(fmt.Sprintf("find-%d", b.findIndex))
+	b.textView.ScrollToHighlight()
+	b.setStatus(fmt.Sprintf("Match %d of %d for %q", b.findIndex+1, b.findCount, b.findQuery))
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHighlightMatchesOutsideLinks(t *testing.T) {
+	text, count := highlightMatches("a doc about docs", "doc", false)
+	if count != 2 {
+		t.Errorf("count = %d, want 2", count)
+	}
+	want := `a ["find-0"]` + activeTheme.match + `doc[-:-:-][""] about ["find-1"]` + activeTheme.match + `doc[-:-:-][""]s`
+	if text != want {
+		t.Errorf("highlightMatches = %q, want %q", text, want)
+	}
+}
+
+func TestHighlightMatchesInsideLinksKeepRegions(t *testing.T) {
+	page := `Read ["link-1"]the docs[""] or ["link-2"]Docs home[""].`
+	text, count := highlightMatches(page, "docs", false)
+	if count != 2 {
+		t.Fatalf("count = %d, want 2", count)
+	}
+	want := `Read ["link-1"]the [""]["find-0"]` + activeTheme.match + `docs[-:-:-][""]["link-1"][""]` +
+		` or ["link-2"][""]["find-1"]` + activeTheme.match + `Docs[-:-:-][""]["link-2"] home[""].`
+	if text != want {
+		t.Errorf("highlightMatches = %q, want %q", text, want)
+	}
+	if got := stripTags(text); got != "Read the docs or Docs home." {
+		t.Errorf("highlighting changed the text to %q", got)
+	}
+	if !strings.Contains(text, `["link-2"] home[""]`) {
+		t.Error("the rest of the link after a match is not in its region")
+	}
+}
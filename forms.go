@@ -0,0 +1,389 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"golang.org/x/net/html"
+)
+
+// Form is a GET or POST form found on the page, reduced to what is needed
+// to fill it in and submit it.
+type Form struct {
+	Action string
+	Method string
+	Fields []FormField
+}
+
+// FormField is one submittable control of a form. Options holds the
+// choices of a select or radio group, Value the initial one.
+type FormField struct {
+	Name    string
+	Type    string
+	Label   string
+	Value   string
+	Options []string
+	Checked bool
+}
+
+// findForms returns every GET and POST form in doc with at least one
+// field. Dialog forms, which only close a dialog, are left out.
+func findForms(doc *html.Node, currentURL string) []Form {
+	var forms []Form
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "form" {
+			method := strings.ToUpper(getAttr(n, "method"))
+			if method == "" {
+				method = "GET"
+			}
+			if method == "GET" || method == "POST" {
+				if form := formFrom(n, currentURL); len(form.Fields) > 0 {
+					form.Method = method
+					forms = append(forms, form)
+				}
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return forms
+}
+
+func formFrom(n *html.Node, currentURL string) Form {
+	action := getAttr(n, "action")
+	if action == "" {
+		action = currentURL
+	}
+	form := Form{Action: resolveURL(currentURL, action)}
+
+	// Labels inside the form name the control whose id they refer to
+	labels := make(map[string]string)
+	var findLabels func(*html.Node)
+	findLabels = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "label" && getAttr(n, "for") != "" {
+			labels[getAttr(n, "for")] = collapseWhitespace(strings.TrimSpace(textContent(n)))
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			findLabels(c)
+		}
+	}
+	findLabels(n)
+
+	// Radio buttons sharing a name become one field with options
+	radios := make(map[string]int)
+	var submitted bool
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type != html.ElementNode {
+			return
+		}
+		name := getAttr(n, "name")
+		_, disabled := attrValue(n, "disabled")
+		if name != "" && !disabled {
+			label := labels[getAttr(n, "id")]
+			if label == "" {
+				label = fieldLabel(n, name)
+			}
+			switch n.Data {
+			case "input":
+				kind := strings.ToLower(getAttr(n, "type"))
+				_, checked := attrValue(n, "checked")
+				switch kind {
+				case "submit", "image":
+					// Only the first named submit button is sent, as if
+					// it had been clicked
+					if !submitted && kind == "submit" {
+						form.Fields = append(form.Fields, FormField{Name: name, Type: "hidden", Value: getAttr(n, "value")})
+						submitted = true
+					}
+				case "button", "reset", "file":
+				case "radio":
+					value := getAttr(n, "value")
+					if value == "" {
+						value = "on"
+					}
+					index, ok := radios[name]
+					if !ok {
+						index = len(form.Fields)
+						radios[name] = index
+						form.Fields = append(form.Fields, FormField{Name: name, Type: "radio", Label: name})
+					}
+					field := &form.Fields[index]
+					field.Options = append(field.Options, value)
+					if checked {
+						field.Value = value
+					}
+				case "checkbox":
+					value := getAttr(n, "value")
+					if value == "" {
+						value = "on"
+					}
+					form.Fields = append(form.Fields, FormField{Name: name, Type: kind, Label: label, Value: value, Checked: checked})
+				default:
+					if kind == "" {
+						kind = "text"
+					}
+					form.Fields = append(form.Fields, FormField{Name: name, Type: kind, Label: label, Value: getAttr(n, "value")})
+				}
+				return
+			case "textarea":
+				form.Fields = append(form.Fields, FormField{Name: name, Type: "textarea", Label: label, Value: textContent(n)})
+				return
+			case "select":
+				field := FormField{Name: name, Type: "select", Label: label}
+				selectOptions(n, &field)
+				form.Fields = append(form.Fields, field)
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c)
+	}
+	return form
+}
+
+// selectOptions collects the options of a select into field, with the
+// selected one, or else the first, as its value.
+func selectOptions(n *html.Node, field *FormField) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		if c.Data == "optgroup" {
+			selectOptions(c, field)
+			continue
+		}
+		if c.Data != "option" {
+			continue
+		}
+		value, ok := attrValue(c, "value")
+		if !ok {
+			value = collapseWhitespace(strings.TrimSpace(textContent(c)))
+		}
+		field.Options = append(field.Options, value)
+		if _, selected := attrValue(c, "selected"); selected || field.Value == "" && len(field.Options) == 1 {
+			field.Value = value
+		}
+	}
+}
+
+// fieldLabel picks a short label for a control without a <label>: its
+// aria-label, placeholder or title, or failing those its name.
+func fieldLabel(n *html.Node, name string) string {
+	for _, attr := range []string{"aria-label", "placeholder", "title"} {
+		if label := strings.TrimSpace(getAttr(n, attr)); label != "" {
+			return label
+		}
+	}
+	return name
+}
+
+// attrValue returns the value of attribute key of n and whether n has it
+// at all, which matters for boolean attributes like checked.
+func attrValue(n *html.Node, key string) (string, bool) {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
+
+// submitURL returns the URL a GET submission of values goes to. The query
+// of the action URL is replaced, as browsers do.
+func (f Form) submitURL(values url.Values) (string, error) {
+	target, err := url.Parse(f.Action)
+	if err != nil {
+		return "", fmt.Errorf("error parsing form action: %v", err)
+	}
+	target.RawQuery = values.Encode()
+	target.Fragment = ""
+	return target.String(), nil
+}
+
+// showForms opens the page's form, letting the user pick one first when
+// there are several.
+func (b *browser) showForms() {
+	if len(b.forms) == 0 {
+		b.setStatus("No forms on this page")
+		return
+	}
+	if len(b.forms) == 1 {
+		b.showForm(b.forms[0])
+		return
+	}
+
+	list := tview.NewList()
+	for _, form := range b.forms {
+		names := make([]string, 0, len(form.Fields))
+		for _, field := range form.Fields {
+			if field.Type != "hidden" {
+				names = append(names, field.Label)
+			}
+		}
+		list.AddItem(tview.Escape(strings.Join(names, ", ")), activeTheme.muted+form.Method+" "+tview.Escape(displayURL(form.Action)), 0, nil)
+	}
+	list.SetSelectedFunc(func(index int, _, _ string, _ rune) {
+		b.closeOverlay("forms")
+		b.showForm(b.forms[index])
+	})
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape || event.Rune() == 'q' {
+			b.closeOverlay("forms")
+			return nil
+		}
+		return event
+	})
+	list.SetBorder(true).SetTitle(" forms (Enter: fill in) ")
+	b.showOverlay("forms", list)
+}
+
+// showForm displays form as editable fields. Submitting a GET form
+// navigates to the action URL with the fields as its query, a POST form
+// sends them as the request body; Esc closes without submitting.
+func (b *browser) showForm(form Form) {
+	view := tview.NewForm()
+	values := make([]func(url.Values), 0, len(form.Fields))
+
+	for _, field := range form.Fields {
+		field := field
+		label := tview.Escape(field.Label) + " "
+		switch field.Type {
+		case "hidden":
+			values = append(values, func(v url.Values) { v.Add(field.Name, field.Value) })
+		case "checkbox":
+			checkbox := tview.NewCheckbox().SetLabel(label).SetChecked(field.Checked)
+			view.AddFormItem(checkbox)
+			values = append(values, func(v url.Values) {
+				if checkbox.IsChecked() {
+					v.Add(field.Name, field.Value)
+				}
+			})
+		case "select", "radio":
+			initial := -1
+			for i, option := range field.Options {
+				if option == field.Value {
+					initial = i
+				}
+			}
+			dropDown := tview.NewDropDown().SetLabel(label).SetOptions(field.Options, nil).SetCurrentOption(initial)
+			view.AddFormItem(dropDown)
+			values = append(values, func(v url.Values) {
+				if _, option := dropDown.GetCurrentOption(); option != "" || field.Type == "select" {
+					v.Add(field.Name, option)
+				}
+			})
+		default:
+			input := tview.NewInputField().SetLabel(label).SetText(field.Value)
+			if field.Type == "password" {
+				input.SetMaskCharacter('*')
+			}
+			view.AddFormItem(input)
+			values = append(values, func(v url.Values) { v.Add(field.Name, input.GetText()) })
+		}
+	}
+
+	view.AddButton("Submit", func() {
+		query := url.Values{}
+		for _, add := range values {
+			add(query)
+		}
+		b.closeOverlay("form")
+		if form.Method == "POST" {
+			b.postForm(form.Action, query)
+			return
+		}
+		target, err := form.submitURL(query)
+		if err != nil {
+			b.setStatus(err.Error())
+			return
+		}
+		b.navigate(target)
+	})
+	view.SetCancelFunc(func() { b.closeOverlay("form") })
+
+	view.SetBorder(true).SetTitle(" " + form.Method + " " + tview.Escape(displayURL(form.Action)) + " (Tab: next field, Esc: cancel) ")
+	b.showOverlay("form", view)
+}
+
+// submitForm posts values to action as an urlencoded form body and returns
+// the response like fetchPage does. The request is not retried, since
+// repeating a POST could repeat its effect.
+func submitForm(action string, values url.Values) (*fetchResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), navigationTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", action, strings.NewReader(values.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	setBasicAuth(req)
+
+	resp, err := httpClient.Do(req)
+	if isTimeout(err) {
+		return nil, errTimeout
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error submitting form: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &statusError{Code: resp.StatusCode, Status: resp.Status}
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxDownloadSize))
+	if isTimeout(err) {
+		return nil, errTimeout
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %v", err)
+	}
+	decoded, err := decodeCharset(body, resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+	return &fetchResult{Body: decoded, Chain: redirectChain(resp), Status: resp.Proto + " " + resp.Status, Header: resp.Header}, nil
+}
+
+// postForm submits values to action and shows the response as the next
+// page, under the URL it ended up at after any redirect.
+func (b *browser) postForm(action string, values url.Values) {
+	b.rememberPosition()
+	b.setStatus(activeTheme.muted + "Submitting to " + tview.Escape(displayURL(action)) + "…[-::-]")
+	go func() {
+		result, err := submitForm(action, values)
+		if err != nil {
+			b.showError(fmt.Sprintf("Error submitting form: %v", err))
+			return
+		}
+		pageURL := result.Chain[len(result.Chain)-1]
+		page, err := renderHTML(result.Body, pageURL)
+		if err != nil {
+			b.showError(fmt.Sprintf("Error rendering HTML: %v", err))
+			return
+		}
+		page.Status, page.Header = result.Status, result.Header
+		b.showPage(pageURL, page, func() {
+			if b.currentURL != "" {
+				b.back = append(b.back, b.currentURL)
+				b.forward = nil
+			}
+		})
+	}()
+}
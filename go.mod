@@ -2,16 +2,18 @@ module github.com/ochsec/just-browsing
 
 go 1.23.5
 
-require golang.org/x/net v0.35.0
+require (
+	github.com/gdamore/tcell/v2 v2.8.1
+	github.com/mattn/go-runewidth v0.0.16
+	github.com/rivo/tview v0.0.0-20241227133733-17b7edb88c57
+	github.com/rivo/uniseg v0.4.7
+	golang.org/x/net v0.35.0
+	golang.org/x/term v0.29.0
+)
 
 require (
 	github.com/gdamore/encoding v1.0.1 // indirect
-	github.com/gdamore/tcell/v2 v2.8.1 // indirect
 	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
-	github.com/mattn/go-runewidth v0.0.16 // indirect
-	github.com/rivo/tview v0.0.0-20241227133733-17b7edb88c57 // indirect
-	github.com/rivo/uniseg v0.4.7 // indirect
 	golang.org/x/sys v0.30.0 // indirect
-	golang.org/x/term v0.29.0 // indirect
 	golang.org/x/text v0.22.0 // indirect
 )
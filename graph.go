@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// linkGraph records the pages visited this session and the links found on
+// each, so the browsed part of a site can be exported for visualization.
+type linkGraph struct {
+	edges map[string][]string
+}
+
+type graphJSON struct {
+	Nodes []string    `json:"nodes"`
+	Edges [][2]string `json:"edges"`
+}
+
+func newLinkGraph() *linkGraph {
+	return &linkGraph{edges: make(map[string][]string)}
+}
+
+func (g *linkGraph) addPage(pageURL string, links []LinkInfo) {
+	seen := make(map[string]bool)
+	var targets []string
+	for _, link := range links {
+		if !seen[link.Href] {
+			seen[link.Href] = true
+			targets = append(targets, link.Href)
+		}
+	}
+	g.edges[pageURL] = targets
+}
+
+func (g *linkGraph) nodes() []string {
+	seen := make(map[string]bool)
+	for from, targets := range g.edges {
+		seen[from] = true
+		for _, to := range targets {
+			seen[to] = true
+		}
+	}
+	nodes := make([]string, 0, len(seen))
+	for node := range seen {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+func (g *linkGraph) sortedPages() []string {
+	pages := make([]string, 0, len(g.edges))
+	for page := range g.edges {
+		pages = append(pages, page)
+	}
+	sort.Strings(pages)
+	return pages
+}
+
+// writeDOT writes the graph in Graphviz format. Visited pages are drawn as
+// boxes, pages only seen as link targets as plain ellipses.
+func (g *linkGraph) writeDOT(w io.Writer) error {
+	var out strings.Builder
+	out.WriteString("digraph links {\n")
+	for _, page := range g.sortedPages() {
+		fmt.Fprintf(&out, "  %q [shape=box];\n", page)
+	}
+	for _, page := range g.sortedPages() {
+		for _, target := range g.edges[page] {
+			fmt.Fprintf(&out, "  %q -> %q;\n", page, target)
+		}
+	}
+	out.WriteString("}\n")
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+func (g *linkGraph) writeJSON(w io.Writer) error {
+	data := graphJSON{Nodes: g.nodes(), Edges: [][2]string{}}
+	for _, page := range g.sortedPages() {
+		for _, target := range g.edges[page] {
+			data.Edges = append(data.Edges, [2]string{page, target})
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(data)
+}
+
+// graphCommand exports the session's link graph as DOT or JSON into
+// exportDir, or into the file named by the second argument.
+func (b *browser) graphCommand(args string) {
+	fields := strings.Fields(args)
+	format := "dot"
+	if len(fields) > 0 {
+		format = fields[0]
+	}
+	if format != "dot" && format != "json" {
+		b.setStatus("Usage: :" + commands["graph"].usage)
+		return
+	}
+	if len(b.graph.edges) == 0 {
+		b.setStatus("No pages visited yet")
+		return
+	}
+
+	filename := filepath.Join(exportDir, fmt.Sprintf("links_%s.%s", time.Now().Format("20060102_150405"), format))
+	if len(fields) > 1 {
+		filename = fields[1]
+	} else if err := os.MkdirAll(exportDir, 0755); err != nil {
+		b.setStatus(fmt.Sprintf("Error creating export dir: %v", err))
+		return
+	}
+
+	out, err := os.Create(filename)
+	if err != nil {
+		b.setStatus(fmt.Sprintf("Error creating file: %v", err))
+		return
+	}
+	defer out.Close()
+
+	if format == "json" {
+		err = b.graph.writeJSON(out)
+	} else {
+		err = b.graph.writeDOT(out)
+	}
+	if err != nil {
+		b.setStatus(fmt.Sprintf("Error writing graph: %v", err))
+		return
+	}
+	b.setStatus(fmt.Sprintf("Wrote link graph of %d pages to %s", len(b.graph.edges), filename))
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// formatHeaders renders a status line and headers sorted by name, one
+// "Name: value" line per value.
+func formatHeaders(status string, header map[string][]string) string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out strings.Builder
+	out.WriteString(activeTheme.accent + tview.Escape(status) + "[-::-]\n")
+	for _, name := range names {
+		for _, value := range header[name] {
+			out.WriteString(activeTheme.muted + tview.Escape(name) + ":[-::-] " + tview.Escape(value) + "\n")
+		}
+	}
+	return out.String()
+}
+
+// toggleHeaders shows or hides the response headers of the current page.
+func (b *browser) toggleHeaders() {
+	if b.pages.HasPage("headers") {
+		b.closeOverlay("headers")
+		return
+	}
+	if b.responseStatus == "" {
+		b.setStatus("No response headers for this page (not fetched over HTTP)")
+		return
+	}
+
+	view := tview.NewTextView().SetDynamicColors(true).SetText(formatHeaders(b.responseStatus, b.responseHeader))
+	view.SetBorder(true).SetTitle(" response headers ")
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape || event.Rune() == 'q' || event.Rune() == 'I' {
+			b.closeOverlay("headers")
+			return nil
+		}
+		return event
+	})
+	b.showOverlay("headers", view)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"golang.org/x/net/html"
+)
+
+// HeadingInfo records an <h1>–<h6> element, its anchor id if it has one,
+// and the rendered line it appears on.
+type HeadingInfo struct {
+	Level int
+	Text  string
+	ID    string
+	Line  int
+}
+
+func headingLevel(n *html.Node) int {
+	if n.Type != html.ElementNode || len(n.Data) != 2 || n.Data[0] != 'h' {
+		return 0
+	}
+	if level := int(n.Data[1] - '0'); level >= 1 && level <= 6 {
+		return level
+	}
+	return 0
+}
+
+// headingStyle returns the style tag for a heading of the given level,
+// more prominent the higher the level.
+func headingStyle(level int) string {
+	switch level {
+	case 1:
+		return activeTheme.accent + "[::bu]"
+	case 2:
+		return activeTheme.accent + "[::b]"
+	case 3:
+		return activeTheme.accent
+	}
+	return "[::b]"
+}
+
+// headingID returns the anchor of a heading: its own id, or the id or name
+// of the first descendant that has one (the common <h2><a id=...> pattern).
+func headingID(n *html.Node) string {
+	if id := getAttr(n, "id"); id != "" {
+		return id
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		if id := getAttr(c, "id"); id != "" {
+			return id
+		}
+		if c.Data == "a" {
+			if name := getAttr(c, "name"); name != "" {
+				return name
+			}
+		}
+		if id := headingID(c); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// sectionURL builds a deep link to a heading anchor on pageURL.
+func sectionURL(pageURL, id string) string {
+	parsedURL, err := url.Parse(pageURL)
+	if err != nil {
+		return pageURL + "#" + id
+	}
+	parsedURL.Fragment = id
+	return parsedURL.String()
+}
+
+// showHeadings opens a table of contents. Enter scrolls to the heading and
+// y copies a deep link to it.
+func (b *browser) showHeadings() {
+	if len(b.headings) == 0 {
+		b.setStatus("No headings on this page")
+		return
+	}
+
+	list := tview.NewList().ShowSecondaryText(false)
+	for _, heading := range b.headings {
+		label := strings.Repeat("  ", heading.Level-1) + tview.Escape(heading.Text)
+		if heading.ID != "" {
+			label += " " + activeTheme.muted + "#" + tview.Escape(heading.ID) + "[-::-]"
+		}
+		list.AddItem(label, "", 0, nil)
+	}
+
+	list.SetSelectedFunc(func(index int, _, _ string, _ rune) {
+		b.closeOverlay("headings")
+		b.scrollTo(b.displayRow(b.headings[index].Line))
+	})
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEscape || event.Rune() == 'q':
+			b.closeOverlay("headings")
+			return nil
+		case event.Rune() == 'y':
+			b.copySectionLink(b.headings[list.GetCurrentItem()])
+			return nil
+		}
+		return event
+	})
+
+	list.SetBorder(true).SetTitle(" contents (Enter: go, y: copy link) ")
+	b.showOverlay("headings", list)
+}
+
+func (b *browser) copySectionLink(heading HeadingInfo) {
+	if heading.ID == "" {
+		b.setStatus(fmt.Sprintf("%q has no anchor to link to", heading.Text))
+		return
+	}
+
+	link := sectionURL(b.currentURL, heading.ID)
+	if err := copyOSC52(link); err != nil {
+		b.setStatus(err.Error())
+		return
+	}
+	b.setStatus("Copied " + link)
+}
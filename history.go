@@ -0,0 +1,28 @@
+package main
+
+// goBack returns to the previous page. History only keeps URLs: pages are
+// loaded again, normally straight from the session cache, so their text
+// and links need not be kept twice.
+func (b *browser) goBack() {
+	if len(b.back) == 0 {
+		b.setStatus("No previous page")
+		return
+	}
+	previous := b.back[len(b.back)-1]
+	b.load(previous, true, func() {
+		b.back = b.back[:len(b.back)-1]
+		b.forward = append(b.forward, b.currentURL)
+	})
+}
+
+func (b *browser) goForward() {
+	if len(b.forward) == 0 {
+		b.setStatus("No next page in history")
+		return
+	}
+	next := b.forward[len(b.forward)-1]
+	b.load(next, true, func() {
+		b.forward = b.forward[:len(b.forward)-1]
+		b.back = append(b.back, b.currentURL)
+	})
+}
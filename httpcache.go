@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// validatedPage is a fetched page body kept with the validators needed to
+// revalidate it, so a 304 Not Modified can be answered from memory. It
+// lives in sessionCache and is evicted with the rest.
+type validatedPage struct {
+	body         string
+	chain        []string
+	etag         string
+	lastModified string
+}
+
+func getValidatedPage(pageURL string) (*validatedPage, bool) {
+	cached, ok := sessionCache.Get("http:" + pageURL)
+	if !ok {
+		return nil, false
+	}
+	return cached.(*validatedPage), true
+}
+
+// putValidatedPage remembers the body of resp for pageURL when the
+// response carries a validator and does not forbid storing it.
+func putValidatedPage(pageURL string, resp *http.Response, body string, chain []string) {
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if etag == "" && lastModified == "" {
+		return
+	}
+	if strings.Contains(strings.ToLower(resp.Header.Get("Cache-Control")), "no-store") {
+		return
+	}
+	page := &validatedPage{body: body, chain: chain, etag: etag, lastModified: lastModified}
+	sessionCache.Put("http:"+pageURL, page, int64(len(body)))
+}
+
+// setValidators makes req conditional on the cached copy changing.
+func (p *validatedPage) setValidators(req *http.Request) {
+	if p.etag != "" {
+		req.Header.Set("If-None-Match", p.etag)
+	}
+	if p.lastModified != "" {
+		req.Header.Set("If-Modified-Since", p.lastModified)
+	}
+}
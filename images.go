@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// probeImage issues a HEAD request for imageURL and reports its content
+// type and size. The size is -1 when the server does not send one.
+func probeImage(imageURL string) (string, int64, error) {
+	if isDataURI(imageURL) {
+		mediaType, data, err := parseDataURI(imageURL)
+		if err != nil {
+			return "", -1, err
+		}
+		return mediaType, int64(len(data)), nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), navigationTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "HEAD", imageURL, nil)
+	if err != nil {
+		return "", -1, fmt.Errorf("error creating request: %v", err)
+	}
+	setBasicAuth(req)
+
+	resp, err := httpClient.Do(req)
+	if isTimeout(err) {
+		return "", -1, errTimeout
+	}
+	if err != nil {
+		return "", -1, fmt.Errorf("error probing image: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", -1, fmt.Errorf("bad status: %s", resp.Status)
+	}
+
+	return resp.Header.Get("Content-Type"), resp.ContentLength, nil
+}
+
+// imageLabel names an image that has no alt text by its file name, for
+// use as the text of a link wrapped around it.
+func imageLabel(src string) string {
+	if parsed, err := url.Parse(src); err == nil && !isDataURI(src) {
+		if name := path.Base(parsed.Path); name != "." && name != "/" {
+			return "image " + name
+		}
+	}
+	return "image"
+}
+
+func describeImage(index, total int, img ImageInfo) string {
+	alt := img.Alt
+	if alt == "" {
+		alt = "(no alt text)"
+	}
+	return fmt.Sprintf("%sImage %d/%d[-::-] %s  %s%s[-::-]", activeTheme.accent, index+1, total,
+		tview.Escape(alt), activeTheme.muted, tview.Escape(shortURL(img.Src)))
+}
+
+// cycleImage moves the image cursor by step, wrapping at either end, and
+// shows the selected image's details in the status bar.
+func (b *browser) cycleImage(step int) {
+	if len(b.images) == 0 {
+		b.setStatus("No images on this page")
+		return
+	}
+
+	b.imageIndex = (b.imageIndex + step + len(b.images)) % len(b.images)
+	index, img := b.imageIndex, b.images[b.imageIndex]
+	summary := describeImage(index, len(b.images), img)
+	b.setStatus(summary)
+
+	go func() {
+		contentType, size, err := probeImage(img.Src)
+		b.app.QueueUpdateDraw(func() {
+			// Ignore results for an image that is no longer selected
+			if b.imageIndex != index || b.images[index].Src != img.Src {
+				return
+			}
+			if err != nil {
+				b.setStatus(fmt.Sprintf("%s  %s%v[-::-]", summary, activeTheme.errorTag, err))
+				return
+			}
+			details := contentType
+			if size >= 0 {
+				details += ", " + formatBytes(size)
+			}
+			b.setStatus(fmt.Sprintf("%s  (%s)", summary, details))
+		})
+	}()
+}
+
+func (b *browser) currentImage() (ImageInfo, bool) {
+	if b.imageIndex < 0 || b.imageIndex >= len(b.images) {
+		b.setStatus("No image selected (press m to cycle through images)")
+		return ImageInfo{}, false
+	}
+	return b.images[b.imageIndex], true
+}
+
+// imageOnLine returns the index of the first image rendered on line, or -1.
+func (b *browser) imageOnLine(line int) int {
+	for i, img := range b.images {
+		if img.Line == line {
+			return i
+		}
+	}
+	return -1
+}
+
+// viewImageInView opens the first image placeholder visible in the text
+// view, starting from the top line.
+func (b *browser) viewImageInView() {
+	row, _ := b.textView.GetScrollOffset()
+	_, _, _, height := b.textView.GetInnerRect()
+	top, bottom := b.logicalLine(row), b.logicalLine(row+height-1)
+	if bottom == -1 {
+		bottom = strings.Count(b.textView.GetText(false), "\n")
+	}
+	for line := top; line >= 0 && line <= bottom; line++ {
+		if index := b.imageOnLine(line); index >= 0 {
+			b.imageIndex = index
+			b.viewCurrentImage()
+			return
+		}
+	}
+	b.setStatus("No image on the visible lines")
+}
+
+func (b *browser) viewCurrentImage() {
+	img, ok := b.currentImage()
+	if !ok {
+		return
+	}
+
+	pageURL := b.currentURL
+	b.setStatus("Loading image…")
+	go func() {
+		filename, err := fetchDecodableImage(img, pageURL, b.statusProgress("Loading image…"))
+		if err == errUnsupportedImage {
+			b.app.QueueUpdateDraw(func() { b.showImage(img, tview.Escape(err.Error())) })
+			return
+		}
+		if err != nil {
+			b.app.QueueUpdateDraw(func() { b.setStatus(err.Error()) })
+			return
+		}
+
+		if sixelEnabled {
+			decoded, err := decodeImageCached(filename)
+			if err != nil {
+				b.app.QueueUpdateDraw(func() { b.setStatus(err.Error()) })
+				return
+			}
+			sixel := encodeSixel(decoded)
+			b.app.QueueUpdateDraw(func() { b.showSixel(img, sixel) })
+			return
+		}
+
+		ascii, err := asciiArt(filename)
+		b.app.QueueUpdateDraw(func() {
+			if err != nil {
+				b.setStatus(err.Error())
+				return
+			}
+			b.showImage(img, ascii)
+		})
+	}()
+}
+
+func (b *browser) showImage(img ImageInfo, ascii string) {
+	view := tview.NewTextView().SetDynamicColors(true).SetWrap(false).SetText(ascii)
+	view.SetBorder(true).SetTitle(" " + img.Src + " ")
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape || event.Rune() == 'q' {
+			b.closeOverlay("image")
+			return nil
+		}
+		return event
+	})
+
+	b.setStatus(describeImage(b.imageIndex, len(b.images), img))
+	b.showOverlay("image", view)
+}
+
+// exportImage downloads the image at imageURL, shown on the page at
+// pageURL, and copies it into exportDir, which unlike downloadDir is kept
+// on exit. Earlier exports of the same name are kept too. It returns the
+// copy's path.
+func exportImage(imageURL, pageURL string, progress progressFunc) (string, error) {
+	filename, err := downloadImageProgress(imageURL, pageURL, progress)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return "", fmt.Errorf("error reading image: %v", err)
+	}
+	if err := os.MkdirAll(exportDir, 0755); err != nil {
+		return "", fmt.Errorf("error creating export dir: %v", err)
+	}
+	ext := filepath.Ext(filename)
+	out, err := createUniqueFile(exportDir, strings.TrimSuffix(filepath.Base(filename), ext), ext)
+	if err != nil {
+		return "", err
+	}
+	_, err = out.Write(data)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(out.Name())
+		return "", fmt.Errorf("error saving image: %v", err)
+	}
+	return out.Name(), nil
+}
+
+func (b *browser) saveCurrentImage() {
+	img, ok := b.currentImage()
+	if !ok {
+		return
+	}
+
+	pageURL := b.currentURL
+	b.setStatus("Downloading image…")
+	go func() {
+		filename, err := exportImage(img.Src, pageURL, b.statusProgress("Downloading image…"))
+		b.app.QueueUpdateDraw(func() {
+			if err != nil {
+				b.setStatus(err.Error())
+				return
+			}
+			b.setStatus("Saved image to " + filename)
+		})
+	}()
+}
+
+// saveAllImages downloads every image on the page concurrently, bounded by
+// the shared download slots, and reports progress in the status bar.
+func (b *browser) saveAllImages() {
+	images, pageURL := b.images, b.currentURL
+	if len(images) == 0 {
+		b.setStatus("No images on this page")
+		return
+	}
+
+	b.setStatus(fmt.Sprintf("Saving %d images…", len(images)))
+	go func() {
+		var saved, failed int32
+		var wg sync.WaitGroup
+		for _, img := range images {
+			wg.Add(1)
+			go func(img ImageInfo) {
+				defer wg.Done()
+				if _, err := exportImage(img.Src, pageURL, nil); err != nil {
+					atomic.AddInt32(&failed, 1)
+				} else {
+					atomic.AddInt32(&saved, 1)
+				}
+				done := atomic.LoadInt32(&saved) + atomic.LoadInt32(&failed)
+				b.app.QueueUpdateDraw(func() {
+					b.setStatus(fmt.Sprintf("Saving images… %d/%d", done, len(images)))
+				})
+			}(img)
+		}
+		wg.Wait()
+
+		b.app.QueueUpdateDraw(func() {
+			b.setStatus(fmt.Sprintf("Saved %d of %d images to %s (%d failed)", saved, len(images), exportDir, failed))
+		})
+	}()
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// maxInlineImages bounds how many images of a page are downloaded when
+// images are rendered inline, so image-heavy pages don't hammer servers.
+const maxInlineImages = 20
+
+// inlineImageWorkers is how many inline images are downloaded at once.
+const inlineImageWorkers = 4
+
+// toggleInlineImages switches between showing images as their alt text and
+// rendering them as ASCII art below the line they appear on.
+func (b *browser) toggleInlineImages() {
+	b.inlineImages = !b.inlineImages
+	if b.inlineImages {
+		b.setStatus("Inline images on")
+		b.renderInlineImages()
+		return
+	}
+
+	b.setStatus("Inline images off")
+	b.stopInlineImages()
+	if b.plainPage != nil {
+		b.redrawPage(b.plainPage)
+		b.plainPage = nil
+	}
+}
+
+// renderInlineImages downloads the current page's images in the
+// background and splices each one into the page as it completes. Images
+// finishing after the page has changed are dropped.
+func (b *browser) renderInlineImages() {
+	if len(b.images) == 0 {
+		return
+	}
+
+	b.stopInlineImages()
+	ctx, cancel := context.WithCancel(context.Background())
+	b.cancelImages = cancel
+
+	page := b.currentPage()
+	b.plainPage = page
+	images := page.Images
+	if len(images) > maxInlineImages {
+		b.setStatus(fmt.Sprintf("Rendering the first %d of %d images inline", maxInlineImages, len(images)))
+		images = images[:maxInlineImages]
+	}
+
+	art := make(map[int]string)
+	go prefetchImages(ctx, b.currentURL, images, inlineImageWorkers, func(i int, filename string) {
+		ascii, err := asciiArt(filename)
+		if err != nil {
+			return
+		}
+		b.app.QueueUpdateDraw(func() {
+			if b.plainPage != page {
+				return
+			}
+			art[i] = ascii
+			b.redrawPage(spliceImages(page, art))
+		})
+	})
+}
+
+// stopInlineImages stops starting downloads for inline images.
+func (b *browser) stopInlineImages() {
+	if b.cancelImages != nil {
+		b.cancelImages()
+		b.cancelImages = nil
+	}
+}
+
+// redrawPage replaces the displayed page with an altered version of the
+// same page, keeping the scroll position and the inline image state.
+func (b *browser) redrawPage(page *Page) {
+	row, col := b.textView.GetScrollOffset()
+	plain := b.plainPage
+	b.setPage(page)
+	b.plainPage = plain
+	b.textView.ScrollTo(row, col)
+}
+
+// spliceImages returns page with art, keyed by image index, inserted below
+// the line each image is rendered on. Everything referencing later lines
+// is moved down accordingly.
+func spliceImages(page *Page, art map[int]string) *Page {
+	insert := make(map[int]string)
+	for i, ascii := range art {
+		insert[page.Images[i].Line] += ascii
+	}
+
+	lines := strings.SplitAfter(page.Text, "\n")
+	shift := make([]int, len(lines))
+	var text strings.Builder
+	added := 0
+	for i, line := range lines {
+		shift[i] = added
+		text.WriteString(line)
+		ascii, ok := insert[i]
+		if !ok {
+			continue
+		}
+		if !strings.HasSuffix(line, "\n") {
+			text.WriteString("\n")
+			added++
+		}
+		text.WriteString(ascii)
+		added += strings.Count(ascii, "\n")
+	}
+	moved := func(line int) int {
+		if line >= 0 && line < len(shift) {
+			return line + shift[line]
+		}
+		return line + added
+	}
+
+	spliced := *page
+	spliced.Text = text.String()
+	spliced.Links = append([]LinkInfo(nil), page.Links...)
+	for i := range spliced.Links {
+		spliced.Links[i].Line = moved(spliced.Links[i].Line)
+	}
+	spliced.Images = append([]ImageInfo(nil), page.Images...)
+	for i := range spliced.Images {
+		spliced.Images[i].Line = moved(spliced.Images[i].Line)
+	}
+	spliced.Tables = append([]TableInfo(nil), page.Tables...)
+	for i := range spliced.Tables {
+		spliced.Tables[i].Line = moved(spliced.Tables[i].Line)
+	}
+	spliced.Headings = append([]HeadingInfo(nil), page.Headings...)
+	for i := range spliced.Headings {
+		spliced.Headings[i].Line = moved(spliced.Headings[i].Line)
+	}
+	spliced.Anchors = make(map[string]int, len(page.Anchors))
+	for id, line := range page.Anchors {
+		spliced.Anchors[id] = moved(line)
+	}
+	return &spliced
+}
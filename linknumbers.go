@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// linkMarker is the [N] shown before link number index.
+func linkMarker(index int) string {
+	if !config.NumberLinks {
+		return ""
+	}
+	return fmt.Sprintf("%s[%d[][-::-] ", activeTheme.muted, index)
+}
+
+// numberedLinkPattern matches a link's region tag together with the
+// linkMarker before it, when there is one.
+var numberedLinkPattern = regexp.MustCompile(`(\[\d+\[\]\[-::-\] )?\["link-(\d+)"\]`)
+
+// linkMarkerPattern matches the number linkMarker puts before a link.
+var linkMarkerPattern = regexp.MustCompile(`\[\d+\[\]\[-::-\] `)
+
+// stripLinkMarkers removes link numbers from text, which otherwise shift
+// whenever a link is added further up the page.
+func stripLinkMarkers(text string) string {
+	return linkMarkerPattern.ReplaceAllString(text, "")
+}
+
+// renumberLinks adds shift to the number of every link in text, in both
+// its marker and its region, for text placed after shift other links.
+func renumberLinks(text string, shift int) string {
+	return numberedLinkPattern.ReplaceAllStringFunc(text, func(match string) string {
+		groups := numberedLinkPattern.FindStringSubmatch(match)
+		index, _ := strconv.Atoi(groups[2])
+		index += shift
+		marker := ""
+		if groups[1] != "" {
+			marker = fmt.Sprintf("[%d[][-::-] ", index)
+		}
+		return marker + fmt.Sprintf(`["link-%d"]`, index)
+	})
+}
+
+// linkRegion wraps the text of link number index in a region so it can be
+// highlighted when selected.
+func linkRegion(index int, text string) string {
+	return fmt.Sprintf(`["link-%d"]%s[""]`, index, text)
+}
+
+// cycleLink moves the link selection by step, wrapping at either end, and
+// scrolls the selected link into view.
+func (b *browser) cycleLink(step int) {
+	if len(b.links) == 0 {
+		b.setStatus("No links on this page")
+		return
+	}
+	if b.selectedLink < 0 && step < 0 {
+		b.selectedLink = 0
+	}
+	b.selectedLink = (b.selectedLink + step + len(b.links)) % len(b.links)
+
+	link := b.links[b.selectedLink]
+	b.textView.Highlight(fmt.Sprintf("link-%d", link.Index))
+	b.textView.ScrollToHighlight()
+	b.setStatus(tview.Escape(displayURL(link.Href)))
+}
+
+// currentLink returns the link selected with Tab, if any.
+func (b *browser) currentLink() (LinkInfo, bool) {
+	if b.selectedLink < 0 || b.selectedLink >= len(b.links) {
+		return LinkInfo{}, false
+	}
+	return b.links[b.selectedLink], true
+}
+
+// handleLinkNumber collects typed digits and follows the link with that
+// number on Enter. Escape and Backspace edit the number while one is being
+// typed. It returns nil when the key was consumed.
+func (b *browser) handleLinkNumber(event *tcell.EventKey) *tcell.EventKey {
+	switch {
+	case event.Key() == tcell.KeyRune && event.Rune() >= '0' && event.Rune() <= '9':
+		if b.linkNumber == "" && event.Rune() == '0' {
+			return event
+		}
+		b.linkNumber += string(event.Rune())
+	case b.linkNumber == "":
+		return event
+	case event.Key() == tcell.KeyEnter:
+		number, _ := strconv.Atoi(b.linkNumber)
+		b.linkNumber = ""
+		b.followLink(number)
+		return nil
+	case event.Key() == tcell.KeyEscape:
+		b.linkNumber = ""
+		b.setStatus("")
+		return nil
+	case event.Key() == tcell.KeyBackspace || event.Key() == tcell.KeyBackspace2:
+		b.linkNumber = b.linkNumber[:len(b.linkNumber)-1]
+	default:
+		return event
+	}
+
+	if b.linkNumber == "" {
+		b.setStatus("")
+	} else {
+		b.setStatus("Follow link " + b.linkNumber + " (Enter to go, Esc to cancel)")
+	}
+	return nil
+}
+
+// linkRegionPattern matches the start of a link region, capturing its
+// number.
+var linkRegionPattern = regexp.MustCompile(`\["link-(\d+)"\]`)
+
+// setLinkLines sets the Line of every link to the line of text its region
+// starts on, which is where the link is actually rendered.
+func setLinkLines(text string, links []LinkInfo) {
+	lines := make(map[int]int)
+	for line, content := range strings.Split(text, "\n") {
+		for _, match := range linkRegionPattern.FindAllStringSubmatch(content, -1) {
+			number, _ := strconv.Atoi(match[1])
+			lines[number] = line
+		}
+	}
+	for i := range links {
+		if line, ok := lines[links[i].Index]; ok {
+			links[i].Line = line
+		}
+	}
+}
+
+// linkClicked follows a link whose region the text view highlighted in
+// response to a mouse click.
+func (b *browser) linkClicked(added, removed, remaining []string) {
+	if !b.clicked || len(added) == 0 {
+		return
+	}
+	var number int
+	if _, err := fmt.Sscanf(added[0], "link-%d", &number); err == nil {
+		b.clicked = false
+		b.followLink(number)
+	}
+}
+
+func (b *browser) followLink(number int) {
+	for _, link := range b.links {
+		if link.Index == number {
+			b.navigate(link.Href)
+			return
+		}
+	}
+	b.setStatus(fmt.Sprintf("No link %d on this page", number))
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// listState is an open <ul> or <ol> while its items are rendered. Next is
+// the number of the following item of an ordered list.
+type listState struct {
+	ordered bool
+	next    int
+}
+
+// newListState starts the list n, honouring the start attribute of <ol>.
+func newListState(n *html.Node) listState {
+	state := listState{ordered: n.Data == "ol", next: 1}
+	if start, err := strconv.Atoi(strings.TrimSpace(getAttr(n, "start"))); err == nil && state.ordered {
+		state.next = start
+	}
+	return state
+}
+
+// definitionIndent is how far <dd> definitions are indented below their
+// term.
+const definitionIndent = "    "
+
+// indentLines prefixes every non-empty line of text with indent.
+func indentLines(text, indent string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = indent + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// listMarker returns the indented bullet or number for the next item of
+// the innermost list in lists, advancing its counter. Items outside any
+// list get a plain bullet.
+func listMarker(lists []listState) string {
+	if len(lists) == 0 {
+		return "• "
+	}
+	indent := strings.Repeat("  ", len(lists)-1)
+	list := &lists[len(lists)-1]
+	if !list.ordered {
+		return indent + "• "
+	}
+	marker := indent + strconv.Itoa(list.next) + ". "
+	list.next++
+	return marker
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// localPath returns the filesystem path a file: URL refers to. On Windows
+// the leading slash before the drive letter is dropped.
+func localPath(u *url.URL) string {
+	p := u.Path
+	if len(p) > 2 && p[0] == '/' && p[2] == ':' {
+		p = p[1:]
+	}
+	return filepath.FromSlash(p)
+}
+
+// isLocalURL reports whether rawURL is a file: URL.
+func isLocalURL(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	return err == nil && strings.EqualFold(parsed.Scheme, "file")
+}
+
+// errLocalFile is returned for file: URLs reached from a page on the
+// network, which could otherwise read the user's files.
+var errLocalFile = errors.New("pages from the network cannot open local files")
+
+// localFileAllowed reports whether the page at fromURL may open targetURL:
+// anything but a file: URL, which only local pages, or no page at all,
+// may open.
+func localFileAllowed(fromURL, targetURL string) bool {
+	return !isLocalURL(targetURL) || fromURL == "" || isLocalURL(fromURL)
+}
+
+// fileURL turns a local path, relative to the working directory or
+// starting with ~/, into an absolute file: URL.
+func fileURL(path string) (string, error) {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("error finding home dir: %v", err)
+		}
+		path = filepath.Join(home, rest)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("error resolving %s: %v", path, err)
+	}
+	slashed := filepath.ToSlash(abs)
+	if !strings.HasPrefix(slashed, "/") {
+		slashed = "/" + slashed
+	}
+	if info, err := os.Stat(abs); err == nil && info.IsDir() && !strings.HasSuffix(slashed, "/") {
+		slashed += "/"
+	}
+	return (&url.URL{Scheme: "file", Path: slashed}).String(), nil
+}
+
+// looksLikePath reports whether input names a local file rather than a
+// host: it starts like a path or names something that exists on disk.
+func looksLikePath(input string) bool {
+	if strings.Contains(input, "://") {
+		return false
+	}
+	for _, prefix := range []string{"/", "./", "../", "~/"} {
+		if strings.HasPrefix(input, prefix) {
+			return true
+		}
+	}
+	_, err := os.Stat(input)
+	return err == nil
+}
+
+// readLocalFile reads the file u points to as a page and returns it with
+// the URL it was read from. Directories are shown as a list of links to
+// their entries, under a URL ending in a slash so the links resolve.
+func readLocalFile(u *url.URL) (string, string, error) {
+	path := localPath(u)
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", "", fmt.Errorf("error opening file: %v", err)
+	}
+	if info.IsDir() {
+		if !strings.HasSuffix(u.Path, "/") {
+			dirURL := *u
+			dirURL.Path += "/"
+			u = &dirURL
+		}
+		listing, err := directoryListing(path)
+		return listing, u.String(), err
+	}
+
+	if info.Size() > maxDownloadSize {
+		return "", "", fmt.Errorf("file is larger than the %s download limit", formatBytes(maxDownloadSize))
+	}
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", fmt.Errorf("error reading file: %v", err)
+	}
+	if ext := strings.ToLower(filepath.Ext(path)); ext != ".html" && ext != ".htm" && ext != ".xhtml" {
+		// Anything else is shown as is rather than parsed as markup,
+		// unless it is a feed
+		decoded, err := decodeCharset(body, "text/plain")
+		if f, ok := parseFeed(decoded); ok {
+			return feedHTML(f), u.String(), err
+		}
+		return "<pre>" + html.EscapeString(decoded) + "</pre>", u.String(), err
+	}
+	decoded, err := decodeCharset(body, "")
+	return decoded, u.String(), err
+}
+
+// directoryListing renders the entries of dir as an HTML page, directories
+// first.
+func directoryListing(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("error reading directory: %v", err)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].IsDir() && !entries[j].IsDir()
+	})
+
+	var out strings.Builder
+	title := html.EscapeString(dir)
+	fmt.Fprintf(&out, "<html><head><title>%s</title></head><body><h1>%s</h1><ul>", title, title)
+	out.WriteString(`<li><a href="../">../</a></li>`)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			name += "/"
+		}
+		href := (&url.URL{Path: name}).String()
+		fmt.Fprintf(&out, `<li><a href="%s">%s</a></li>`, html.EscapeString(href), html.EscapeString(name))
+	}
+	out.WriteString("</ul></body></html>")
+	return out.String(), nil
+}
+
+// copyLocalImage copies the image file u points to into downloadDir, so
+// local images are handled like downloaded ones.
+func copyLocalImage(imageURL string, u *url.URL) (string, error) {
+	data, err := os.ReadFile(localPath(u))
+	if err != nil {
+		return "", fmt.Errorf("error reading image: %v", err)
+	}
+	if int64(len(data)) > maxDownloadSize {
+		return "", fmt.Errorf("image is larger than the %s download limit", formatBytes(maxDownloadSize))
+	}
+	filename := generateUniqueFilename(filepath.Ext(u.Path))
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		return "", fmt.Errorf("error saving image: %v", err)
+	}
+	rememberImageFile(imageURL, filename)
+	return filename, nil
+}
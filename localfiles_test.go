@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestLocalFileAllowed(t *testing.T) {
+	tests := []struct {
+		from, target string
+		want         bool
+	}{
+		{"https://example.com/", "file:///etc/passwd", false},
+		{"http://example.com/", "FILE:///etc/passwd", false},
+		{"file:///home/me/index.html", "file:///home/me/notes.txt", true},
+		{"", "file:///home/me/notes.txt", true},
+		{"https://example.com/", "https://example.org/", true},
+		{"file:///home/me/index.html", "https://example.org/", true},
+	}
+	for _, tt := range tests {
+		if got := localFileAllowed(tt.from, tt.target); got != tt.want {
+			t.Errorf("localFileAllowed(%q, %q) = %v, want %v", tt.from, tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestNetworkPagesCannotLoadLocalImages(t *testing.T) {
+	if _, err := downloadImage("file:///etc/hostname", "https://example.com/"); err != errLocalFile {
+		t.Errorf("downloadImage from a network page = %v, want errLocalFile", err)
+	}
+}
+
+func TestFileBaseIgnoredOnNetworkPages(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<base href="file:///etc/"><a href="passwd">x</a>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := documentBase(doc, "https://example.com/page"); got != "https://example.com/page" {
+		t.Errorf("documentBase on a network page = %q, want the page URL", got)
+	}
+	if got := documentBase(doc, "file:///home/me/page.html"); got != "file:///etc/" {
+		t.Errorf("documentBase on a local page = %q, want the file: base", got)
+	}
+}
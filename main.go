@@ -1,8 +1,13 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
 	"io"
 	"math/rand"
 	"net/http"
@@ -12,8 +17,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/gdamore/tcell/v2"
-	"github.com/rivo/tview"
 	"golang.org/x/net/html"
 )
 
@@ -21,22 +24,102 @@ type LinkInfo struct {
 	Text string
 	Href string
 	Line int
+
+	// Map is the name of the image map this link is an <area> of.
+	Map string
+
+	// Index is the link's number, shown before it and typed to follow it.
+	Index int
 }
 
 type ImageInfo struct {
 	Src  string
 	Alt  string
+	Line int
+
+	// Candidates are alternate sources from srcset, tried in order when
+	// Src cannot be downloaded or decoded.
+	Candidates []string
+
+	// UseMap names the <map> whose areas link from this image.
+	UseMap string
+}
+
+// Page is the rendered form of an HTML document: the display text plus
+// everything the UI can navigate to or inspect.
+type Page struct {
+	Text     string
+	Links    []LinkInfo
+	Images   []ImageInfo
+	Tables   []TableInfo
+	Headings []HeadingInfo
+
+	// Anchors maps the targets of #fragment links, element ids and
+	// <a name> values, to the line they are rendered on.
+	Anchors map[string]int
+
+	// Words counts the words of Text, for the reading time estimate.
+	Words int
+
+	// Canonical is the page's rel="canonical" URL when it is valid and
+	// same-origin, used to key history and the cache.
+	Canonical string
+
+	// Source is the HTML the page was rendered from.
+	Source string
+
+	// Collapsed counts blocks replaced by a placeholder, see collapse.go.
+	// Expanded is set when those blocks were rendered in full instead.
+	Collapsed int
+	Expanded  bool
+
+	// Next is the URL of the following page in a paginated sequence.
+	Next string
+
+	// Search is the page's primary search form, if one was detected.
+	Search *SearchForm
+
+	// Forms are the page's GET and POST forms, see forms.go.
+	Forms []Form
+
+	// Status and Header describe the HTTP response the page came from.
+	Status string
+	Header http.Header
+
+	Title string
+
+	// Refresh is the target of a <meta http-equiv="refresh">, followed
+	// after RefreshDelay.
+	Refresh      string
+	RefreshDelay time.Duration
 }
 
 var asciiChars = []string{" ", ".", ":", "-", "=", "+", "*", "#", "%", "@"}
-var downloadDir = "downloads"
+
+// defaultDownloadDir is where downloads go unless -download-dir is given.
+// Only this directory is emptied on exit.
+const defaultDownloadDir = "downloads"
+
+var downloadDir = defaultDownloadDir
+
+// renderWidth is the number of columns rendered text is laid out for when
+// the layout depends on it, such as right-aligned RTL paragraphs.
+var renderWidth = 80
+
+// activeRamp is the character ramp ASCII art images are drawn with.
+var activeRamp = asciiChars
+
+// asciiWidth is the width of ASCII art images in characters. Zero follows
+// the terminal width.
+var asciiWidth int
 
 func init() {
-	os.MkdirAll(downloadDir, 0755)
 	rand.Seed(time.Now().UnixNano())
 }
 
 func cleanupDownloads() {
+	forgetImageFiles()
+
 	files, err := filepath.Glob(filepath.Join(downloadDir, "*"))
 	if err != nil {
 		fmt.Printf("Error finding download files: %v\n", err)
@@ -57,103 +140,314 @@ func generateUniqueFilename(ext string) string {
 }
 
 func fetchURL(inputURL string) (string, error) {
+	body, _, err := fetchURLRedirects(inputURL)
+	return body, err
+}
+
+// fetchURLRedirects is fetchURL that also returns the redirect chain
+// followed, ending with the URL the body was actually served from.
+func fetchURLRedirects(inputURL string) (string, []string, error) {
+	result, err := fetchPage(inputURL)
+	if err != nil {
+		return "", nil, err
+	}
+	return result.Body, result.Chain, nil
+}
+
+// fetchResult is a fetched page together with the response metadata that
+// is otherwise discarded once the status has been checked. Status and
+// Header are empty for pages that were not fetched over HTTP.
+type fetchResult struct {
+	Body   string
+	Chain  []string
+	Status string
+	Header http.Header
+}
+
+// fetchPage fetches inputURL and returns the decoded body, the redirect
+// chain and the final response's status line and headers.
+func fetchPage(inputURL string) (*fetchResult, error) {
 	parsedURL, err := url.Parse(inputURL)
 	if err != nil {
-		return "", fmt.Errorf("error parsing URL: %v", err)
+		return nil, fmt.Errorf("error parsing URL: %v", err)
 	}
 
 	if parsedURL.Scheme == "" {
 		parsedURL.Scheme = "https"
 	}
+	if strings.EqualFold(parsedURL.Scheme, "file") {
+		body, served, err := readLocalFile(parsedURL)
+		if err != nil {
+			return nil, err
+		}
+		chain := []string{parsedURL.String()}
+		if served != chain[0] {
+			chain = append(chain, served)
+		}
+		return &fetchResult{Body: body, Chain: chain}, nil
+	}
 
-	resp, err := http.Get(parsedURL.String())
+	if body, ok, err := fetchFromWARC(parsedURL.String()); ok {
+		if err != nil {
+			return nil, err
+		}
+		return &fetchResult{Body: body, Chain: []string{parsedURL.String()}}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), navigationTimeout)
+	defer cancel()
+
+	cached, revalidate := getValidatedPage(parsedURL.String())
+	resp, err := doWithRetry(ctx, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", parsedURL.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		setBasicAuth(req)
+		if revalidate {
+			cached.setValidators(req)
+		}
+		return req, nil
+	})
+	if isTimeout(err) {
+		return nil, errTimeout
+	}
 	if err != nil {
-		return "", fmt.Errorf("error fetching URL: %v", err)
+		return nil, fmt.Errorf("error fetching URL: %v", err)
 	}
 	defer resp.Body.Close()
 
+	status := resp.Proto + " " + resp.Status
+	if resp.StatusCode == http.StatusNotModified && revalidate {
+		return &fetchResult{Body: cached.body, Chain: cached.chain, Status: status, Header: resp.Header}, nil
+	}
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("bad status: %s", resp.Status)
+		return nil, &statusError{Code: resp.StatusCode, Status: resp.Status}
 	}
 
 	body, err := io.ReadAll(resp.Body)
+	if isTimeout(err) {
+		return nil, errTimeout
+	}
 	if err != nil {
-		return "", fmt.Errorf("error reading response body: %v", err)
+		return nil, fmt.Errorf("error reading response body: %v", err)
 	}
 
-	return string(body), nil
+	decoded, err := decodeCharset(body, resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+	decoded = feedAsHTML(decoded, resp.Header.Get("Content-Type"))
+	chain := redirectChain(resp)
+	putValidatedPage(parsedURL.String(), resp, decoded, chain)
+	return &fetchResult{Body: decoded, Chain: chain, Status: status, Header: resp.Header}, nil
 }
 
 func resolveURL(baseURL, linkHref string) string {
+	if isDataURI(linkHref) {
+		return linkHref
+	}
+
+	linkHref = cleanHref(linkHref)
 	base, err := url.Parse(baseURL)
 	if err != nil {
 		return linkHref
 	}
 
+	// Protocol-relative links (//host/path) take the scheme of base and
+	// fragment-only ones (#id) its path and query, as ResolveReference does
 	link, err := url.Parse(linkHref)
 	if err != nil {
+		// Left for fetchURL to report; nothing better can be made of it
+		return linkHref
+	}
+	if link.Opaque != "" {
+		// mailto:, tel:, javascript: and the like are kept exactly as
+		// written so navigation can tell what they are
 		return linkHref
 	}
 
+	// Credentials are left out so they are not shown with the link or
+	// passed on; setBasicAuth still sends them to the same site
 	resolvedURL := base.ResolveReference(link)
+	resolvedURL.User = nil
 	return resolvedURL.String()
 }
 
-func downloadImage(imageURL string) (string, error) {
-	resp, err := http.Get(imageURL)
+// documentBase returns the URL relative links in doc resolve against: the
+// first <base> element with an href, or pageURL when it has none. Bases
+// other than web or file URLs are ignored, as browsers do, and file: bases
+// only count on local pages.
+func documentBase(doc *html.Node, pageURL string) string {
+	var href string
+	var found bool
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if found {
+			return
+		}
+		if n.Type == html.ElementNode && n.Data == "base" {
+			href, found = attrValue(n, "href")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	if !found {
+		return pageURL
+	}
+	base, err := url.Parse(resolveURL(pageURL, href))
+	if err != nil {
+		return pageURL
+	}
+	switch strings.ToLower(base.Scheme) {
+	case "http", "https":
+		return base.String()
+	case "file":
+		if localFileAllowed(pageURL, base.String()) {
+			return base.String()
+		}
+	}
+	return pageURL
+}
+
+// cleanHref repairs an href the way browsers do before parsing it: outer
+// whitespace is trimmed, tabs and newlines inside are dropped and stray
+// percent signs are escaped, so "100%.html" still resolves.
+func cleanHref(href string) string {
+	href = strings.Trim(href, " \t\n\f\r")
+	href = strings.NewReplacer("\t", "", "\n", "", "\r", "").Replace(href)
+
+	var out strings.Builder
+	for i := 0; i < len(href); i++ {
+		out.WriteByte(href[i])
+		if href[i] == '%' && (i+2 >= len(href) || !isHex(href[i+1]) || !isHex(href[i+2])) {
+			out.WriteString("25")
+		}
+	}
+	return out.String()
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+// samePageFragment reports whether href, found on the page at pageURL,
+// points into that same page, and returns the fragment it points to.
+func samePageFragment(pageURL, href string) (string, bool) {
+	target, err := url.Parse(resolveURL(pageURL, href))
+	if err != nil || target.Fragment == "" {
+		return "", false
+	}
+	page, err := url.Parse(pageURL)
+	if err != nil {
+		return "", false
+	}
+	fragment := target.Fragment
+	target.Fragment, target.RawFragment = "", ""
+	page.Fragment, page.RawFragment = "", ""
+	return fragment, target.String() == page.String()
+}
+
+// downloadImage saves the image at imageURL, shown on the page at pageURL,
+// to a file and returns its name.
+func downloadImage(imageURL, pageURL string) (string, error) {
+	return downloadImageProgress(imageURL, pageURL, nil)
+}
+
+// downloadImageProgress is downloadImage reporting its progress to
+// progress, which may be nil.
+func downloadImageProgress(imageURL, pageURL string, progress progressFunc) (string, error) {
+	if !localFileAllowed(pageURL, imageURL) {
+		return "", errLocalFile
+	}
+	if isDataURI(imageURL) {
+		return saveDataURI(imageURL)
+	}
+	if filename, ok := cachedImageFile(imageURL); ok {
+		return filename, nil
+	}
+	if parsed, err := url.Parse(imageURL); err == nil && strings.EqualFold(parsed.Scheme, "file") {
+		return copyLocalImage(imageURL, parsed)
+	}
+
+	release := acquireDownloadSlot()
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), navigationTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", imageURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Accept", decodableAccept)
+	setBasicAuth(req)
+
+	resp, err := httpClient.Do(req)
+	if isTimeout(err) {
+		return "", errTimeout
+	}
 	if err != nil {
 		return "", fmt.Errorf("error downloading image: %v", err)
 	}
 	defer resp.Body.Close()
 
-	ext := filepath.Ext(imageURL)
-	if ext == "" {
-		ext = ".jpg"
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("bad status: %s", resp.Status)
+	}
+	if resp.ContentLength > maxDownloadSize {
+		return "", fmt.Errorf("image is larger than the %s download limit", formatBytes(maxDownloadSize))
 	}
 
-	filename := generateUniqueFilename(ext)
-
-	out, err := os.Create(filename)
+	out, err := createDownloadFile(resp, imageURL, ".jpg")
 	if err != nil {
-		return "", fmt.Errorf("error creating file: %v", err)
+		return "", err
 	}
 	defer out.Close()
+	filename := out.Name()
 
-	_, err = io.Copy(out, resp.Body)
+	written, err := io.Copy(out, newProgressReader(io.LimitReader(resp.Body, maxDownloadSize+1), resp.ContentLength, progress))
+	if isTimeout(err) {
+		out.Close()
+		os.Remove(filename)
+		return "", errTimeout
+	}
 	if err != nil {
 		return "", fmt.Errorf("error saving image: %v", err)
 	}
+	if written > maxDownloadSize {
+		out.Close()
+		os.Remove(filename)
+		return "", fmt.Errorf("image is larger than the %s download limit", formatBytes(maxDownloadSize))
+	}
 
+	rememberImageFile(imageURL, filename)
 	return filename, nil
 }
 
-func imageToASCII(filename string) (string, error) {
-	file, err := os.Open(filename)
+func imageToASCII(filename string, width int, ramp []string) (string, error) {
+	img, err := decodeImageCached(filename)
 	if err != nil {
-		return "", fmt.Errorf("error opening image: %v", err)
+		return "", err
 	}
-	defer file.Close()
 
-	img, _, err := image.Decode(file)
+	bounds := img.Bounds()
+	width, height, err := asciiSize(bounds, width)
 	if err != nil {
-		return "", fmt.Errorf("error decoding image: %v", err)
+		return "", err
 	}
 
-	bounds := img.Bounds()
-	width := 80
-	height := width * bounds.Dy() / bounds.Dx()
-
 	var ascii strings.Builder
 	for y := 0; y < height; y++ {
 		for x := 0; x < width; x++ {
 			origX := x * bounds.Dx() / width
 			origY := y * bounds.Dy() / height
-			
+
 			c := img.At(origX, origY)
 			r, g, b, _ := c.RGBA()
-			brightness := (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 65535.0
-			charIndex := int(brightness * float64(len(asciiChars)-1))
-			ascii.WriteString(asciiChars[charIndex])
+			ascii.WriteString(brightnessChar(r, g, b, ramp))
 		}
 		ascii.WriteString("\n")
 	}
@@ -161,22 +455,234 @@ func imageToASCII(filename string) (string, error) {
 	return ascii.String(), nil
 }
 
-func extractContent(node *html.Node, currentURL string) (string, []LinkInfo, []ImageInfo) {
-	var text string
-	var links []LinkInfo
-	var images []ImageInfo
+// imageToASCIIColor is imageToASCII with each character tinted with the
+// color of its pixel using tview color tags. Near-white pixels, usually
+// the image background, are left blank instead of becoming solid blocks.
+func imageToASCIIColor(filename string, width int, ramp []string) (string, error) {
+	img, err := decodeImageCached(filename)
+	if err != nil {
+		return "", err
+	}
+
+	bounds := img.Bounds()
+	width, height, err := asciiSize(bounds, width)
+	if err != nil {
+		return "", err
+	}
+
+	var ascii strings.Builder
+	for y := 0; y < height; y++ {
+		var tag string
+		for x := 0; x < width; x++ {
+			origX := x * bounds.Dx() / width
+			origY := y * bounds.Dy() / height
+
+			r, g, b, _ := img.At(bounds.Min.X+origX, bounds.Min.Y+origY).RGBA()
+			if r>>8 > 0xf0 && g>>8 > 0xf0 && b>>8 > 0xf0 {
+				ascii.WriteString(" ")
+				continue
+			}
+			if next := fmt.Sprintf("[#%02x%02x%02x]", r>>8, g>>8, b>>8); next != tag {
+				tag = next
+				ascii.WriteString(tag)
+			}
+			ascii.WriteString(brightnessChar(r, g, b, ramp))
+		}
+		ascii.WriteString("[-]\n")
+	}
+
+	return ascii.String(), nil
+}
+
+// asciiSize returns the size in characters of ASCII art width characters
+// wide for an image with bounds, keeping its aspect ratio. A width of zero
+// or less uses the default of 80.
+func asciiSize(bounds image.Rectangle, width int) (int, int, error) {
+	if bounds.Dx() <= 0 || bounds.Dy() <= 0 {
+		return 0, 0, fmt.Errorf("image has no pixels")
+	}
+	if width <= 0 {
+		width = 80
+	}
+	return width, max(width*bounds.Dy()/bounds.Dx(), 1), nil
+}
+
+// brightnessChar maps a 16-bit RGB color to a character of ramp, ordered
+// from dark to bright, by its perceived brightness.
+func brightnessChar(r, g, b uint32, ramp []string) string {
+	if len(ramp) == 0 {
+		ramp = asciiChars
+	}
+	brightness := (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 65535.0
+	index := int(brightness*float64(len(ramp)-1) + 0.5)
+	return ramp[min(max(index, 0), len(ramp)-1)]
+}
+
+// asciiRamp builds the character ramp for ASCII art from chars, or the
+// default ramp when chars is empty, reversed when invert is set.
+func asciiRamp(chars string, invert bool) []string {
+	ramp := asciiChars
+	if chars != "" {
+		ramp = strings.Split(chars, "")
+	}
+	if invert {
+		reversed := make([]string, len(ramp))
+		for i, c := range ramp {
+			reversed[len(ramp)-1-i] = c
+		}
+		ramp = reversed
+	}
+	return ramp
+}
+
+// artWidth is the width of ASCII art images: asciiWidth, or the terminal
+// width when that is not set.
+func artWidth() int {
+	if asciiWidth > 0 {
+		return asciiWidth
+	}
+	return renderWidth
+}
+
+// asciiArt renders an image with imageToASCII, or in color when
+// ColorImages is set. It is artWidth characters wide.
+func asciiArt(filename string) (string, error) {
+	width := artWidth()
+	if config.ColorImages {
+		return imageToASCIIColor(filename, width, activeRamp)
+	}
+	return imageToASCII(filename, width, activeRamp)
+}
+
+// maxRenderDepth is how deeply nested an element may be and still be
+// rendered. Browsers cap DOM depth similarly; without a limit hostile
+// markup can make rendering arbitrarily slow.
+const maxRenderDepth = 512
+
+func extractContent(node *html.Node, currentURL string, opts renderOptions) *Page {
+	var tables []TableInfo
+	var headings []HeadingInfo
 	var lineCount int
+	var direction string
+	var collapsed int
+	var preformatted int
+	var lists []listState
+	var allowedDepth int
+	var depth int
+
+	// Links and images beyond the configured limits are rendered as text
+	// but not collected, keeping huge pages such as sitemaps responsive.
+	var linkCount, imageCount, droppedLinks, droppedImages int
+	keepLink := func() bool {
+		if config.MaxLinks > 0 && linkCount >= config.MaxLinks {
+			droppedLinks++
+			return false
+		}
+		linkCount++
+		return true
+	}
+	keepImage := func() bool {
+		if config.MaxImages > 0 && imageCount >= config.MaxImages {
+			droppedImages++
+			return false
+		}
+		imageCount++
+		return true
+	}
+	// spaceBefore records whether the text emitted so far ends in
+	// whitespace, so collapsed text does not start with a second space.
+	spaceBefore := true
+
+	// recordAnchor notes the line n starts on if fragments can point to
+	// it. The first element with a given id wins, as in browsers.
+	anchors := make(map[string]int)
+	recordAnchor := func(n *html.Node) {
+		if n.Type != html.ElementNode {
+			return
+		}
+		id := getAttr(n, "id")
+		if id == "" && n.Data == "a" {
+			id = getAttr(n, "name")
+		}
+		if _, seen := anchors[id]; id != "" && !seen {
+			anchors[id] = lineCount
+		}
+	}
 
 	var extractFunc func(*html.Node, int) (string, []LinkInfo, []ImageInfo)
+	var extractChildren func(*html.Node, string, []LinkInfo, []ImageInfo) (string, []LinkInfo, []ImageInfo)
 	extractFunc = func(n *html.Node, currentLine int) (string, []LinkInfo, []ImageInfo) {
 		var extractedText string
 		var extractedLinks []LinkInfo
 		var extractedImages []ImageInfo
 
-		if n.Type == html.ElementNode && (n.Data == "script" || n.Data == "style") {
+		depth++
+		defer func() { depth-- }()
+		if depth > maxRenderDepth {
+			return "", nil, nil
+		}
+
+		if skippedElement(n) || opts.skip[n] {
 			return "", nil, nil
 		}
 
+		// With an allowlist only allowed elements and their descendants
+		// are rendered; everything else is just searched for them.
+		if n.Type == html.ElementNode && tagAllowed(n.Data) {
+			allowedDepth++
+			defer func() { allowedDepth-- }()
+		}
+		if len(config.AllowTags) > 0 && allowedDepth == 0 {
+			if n.Type == html.TextNode {
+				return "", nil, nil
+			}
+			return extractChildren(n, "", nil, nil)
+		}
+
+		if !opts.expand && isConsentBanner(n) {
+			collapsed++
+			lineCount++
+			return collapsedPlaceholder("Cookie consent notice"), nil, nil
+		}
+
+		if n.Type == html.ElementNode && n.Data == "dialog" {
+			if !opts.expand && !isOpenDialog(n) {
+				collapsed++
+				lineCount++
+				return collapsedPlaceholder(dialogMarker(n)), nil, nil
+			}
+			extractedText = fmt.Sprintf("%s%s[-::-]\n", activeTheme.muted, dialogMarker(n))
+			lineCount++
+		}
+
+		if n.Type == html.ElementNode {
+			if dir := elementDirection(n); dir != "" {
+				outer := direction
+				direction = dir
+				defer func() { direction = outer }()
+			}
+		}
+
+		// Code blocks keep their whitespace; <code> counts as one when
+		// it spans several lines outside a <pre>
+		if isElement(n, "pre") || isElement(n, "code") && strings.Contains(textContent(n), "\n") {
+			preformatted++
+			defer func() { preformatted-- }()
+		}
+
+		if n.Type == html.ElementNode && n.Data == "br" && htmlWhitespace() {
+			lineCount++
+			spaceBefore = true
+			return "\n", nil, nil
+		}
+
+		// Horizontal rules become a line of dashes as wide as images
+		if n.Type == html.ElementNode && n.Data == "hr" {
+			lineCount++
+			spaceBefore = true
+			return activeTheme.muted + strings.Repeat("-", artWidth()) + "[-::-]\n", nil, nil
+		}
+
 		if n.Type == html.ElementNode && n.Data == "a" {
 			linkText := ""
 			linkHref := ""
@@ -185,44 +691,304 @@ func extractContent(node *html.Node, currentURL string) (string, []LinkInfo, []I
 					linkHref = attr.Val
 				}
 			}
-			
+
+			// The children's text is trimmed below, so the lines they
+			// counted are counted again from what is actually emitted.
+			// If this turns out not to be a link, they are extracted
+			// again as ordinary content, so the counts are kept to be
+			// restored.
+			lineBefore := lineCount
+			linksBefore, imagesBefore := linkCount, imageCount
+			droppedLinksBefore, droppedImagesBefore := droppedLinks, droppedImages
+			var childLinks []LinkInfo
+			var childImages []ImageInfo
 			for c := n.FirstChild; c != nil; c = c.NextSibling {
-				childText, _, _ := extractFunc(c, currentLine)
+				childText, links, images := extractFunc(c, currentLine)
 				linkText += childText
+				childLinks = append(childLinks, links...)
+				childImages = append(childImages, images...)
 			}
-			
+			lineCount = lineBefore
+
+			rawText := linkText
 			linkText = strings.TrimSpace(linkText)
+			if linkText == "" {
+				// Icon-only links are usually labelled for screen readers;
+				// keep the label apart from neighbouring icons
+				linkText = strings.TrimSpace(getAttr(n, "aria-label"))
+				if linkText == "" {
+					linkText = strings.TrimSpace(getAttr(n, "title"))
+				}
+				if linkText == "" && len(childImages) > 0 {
+					// An image without alt text still needs something
+					// to click on
+					linkText = imageLabel(childImages[0].Src)
+				}
+				rawText = " " + linkText + " "
+			}
+			if htmlWhitespace() {
+				linkText = strings.Join(strings.Fields(linkText), " ")
+			}
+			if linkText == "" || linkHref == "" {
+				linkCount, imageCount = linksBefore, imagesBefore
+				droppedLinks, droppedImages = droppedLinksBefore, droppedImagesBefore
+			}
 			if linkText != "" && linkHref != "" {
+				// Images and links inside the link are kept, so a linked
+				// image can still be viewed and saved
+				extractedLinks = append(extractedLinks, childLinks...)
+				extractedImages = append(extractedImages, childImages...)
 				resolvedLink := resolveURL(currentURL, linkHref)
-				extractedLinks = append(extractedLinks, LinkInfo{
-					Text: linkText, 
-					Href: resolvedLink, 
-					Line: currentLine,
-				})
-				return linkText + " ", extractedLinks, extractedImages
+				display := linkText
+				if keepLink() {
+					extractedLinks = append(extractedLinks, LinkInfo{
+						Text:  linkText,
+						Href:  resolvedLink,
+						Line:  currentLine,
+						Index: linkCount,
+					})
+					display = linkMarker(linkCount) + linkRegion(linkCount, linkText)
+				}
+				lineCount += strings.Count(display, "\n")
+				if htmlWhitespace() {
+					text := keepEdgeSpaces(rawText, display)
+					if spaceBefore {
+						text = strings.TrimPrefix(text, " ")
+					}
+					spaceBefore = strings.HasSuffix(text, " ")
+					return text, extractedLinks, extractedImages
+				}
+				return display + " ", extractedLinks, extractedImages
+			}
+		}
+
+		if n.Type == html.ElementNode && n.Data == "address" {
+			contactText, links, images := extractChildren(n, "", nil, nil)
+			// formatAddress adds a blank line before and after the block
+			lineCount += 2
+			return formatAddress(contactText), links, images
+		}
+
+		if n.Type == html.ElementNode && n.Data == "textarea" {
+			var content string
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if c.Type == html.TextNode {
+					content += c.Data
+				}
+			}
+			block := formatTextarea(content)
+			lineCount += strings.Count(block, "\n")
+			return block, nil, nil
+		}
+
+		if level := headingLevel(n); level > 0 {
+			// Headings are set off by a blank line before and after
+			if lineCount > 0 {
+				extractedText += "\n"
+				lineCount++
+			}
+			headings = append(headings, HeadingInfo{
+				Level: level,
+				Text:  nodeText(n),
+				ID:    headingID(n),
+				Line:  lineCount,
+			})
+			rendered, links, images := extractChildren(n, "", nil, nil)
+			text := strings.TrimSpace(rendered)
+			if text == "" {
+				return extractedText, links, images
+			}
+			lineCount += strings.Count(text, "\n") + 2 - strings.Count(rendered, "\n")
+			spaceBefore = true
+			return extractedText + headingStyle(level) + text + "[-::-]\n\n", links, images
+		}
+
+		if n.Type == html.ElementNode && (n.Data == "ul" || n.Data == "ol") {
+			lists = append(lists, newListState(n))
+			defer func() { lists = lists[:len(lists)-1] }()
+		}
+
+		if n.Type == html.ElementNode && n.Data == "li" {
+			marker := listMarker(lists)
+			spaceBefore = true
+			text, links, images := extractChildren(n, "", nil, nil)
+			if strings.TrimSpace(text) == "" {
+				return "", links, images
+			}
+			if !htmlWhitespace() && !strings.HasSuffix(text, "\n") {
+				// Items ending in a link would run into the next item
+				text = strings.TrimRight(text, " ") + "\n"
+				lineCount++
+			}
+			return marker + strings.TrimLeft(text, " \n"), links, images
+		}
+
+		// Definition list terms are set in bold on a line of their own,
+		// with their definitions indented below
+		if n.Type == html.ElementNode && (n.Data == "dt" || n.Data == "dd") {
+			spaceBefore = true
+			text, links, images := extractChildren(n, "", nil, nil)
+			if strings.TrimSpace(text) == "" {
+				return "", links, images
+			}
+			if !htmlWhitespace() && !strings.HasSuffix(text, "\n") {
+				text = strings.TrimRight(text, " ") + "\n"
+				lineCount++
+			}
+			text = strings.TrimLeft(text, " \n")
+			if n.Data == "dt" {
+				// The style ends before the newline so the line still
+				// counts as ended
+				body := strings.TrimSuffix(text, "\n")
+				return "[::b]" + body + "[::-]" + text[len(body):], links, images
+			}
+			return indentLines(text, definitionIndent), links, images
+		}
+
+		if n.Type == html.ElementNode && n.Data == "table" {
+			rows, header := tableRows(n)
+			tables = append(tables, TableInfo{Rows: rows, Header: header, Line: lineCount})
+			extractedText = fmt.Sprintf("\n%s(table %d, press t to scroll it)[-::-]\n", activeTheme.muted, len(tables))
+			lineCount += 2
+
+			// Cells are rendered on their own so links and images inside
+			// them are kept, then laid out as an aligned grid
+			cells, header := tableCells(n)
+			start := lineCount
+			grid := make([][]string, len(cells))
+			for r, row := range cells {
+				line := start + tableGridLine(r, header)
+				for _, cell := range row {
+					lineCount = line
+					spaceBefore = true
+					text, links, images := extractChildren(cell, "", nil, nil)
+					for i := range links {
+						links[i].Line = line
+					}
+					for i := range images {
+						images[i].Line = line
+					}
+					extractedLinks = append(extractedLinks, links...)
+					extractedImages = append(extractedImages, images...)
+					grid[r] = append(grid[r], strings.Join(strings.Fields(text), " "))
+				}
+			}
+			table := formatTable(grid, header)
+			lineCount = start + strings.Count(table, "\n")
+			spaceBefore = true
+			return extractedText + table, extractedLinks, extractedImages
+		}
+
+		if n.Type == html.ElementNode && n.Data == "map" {
+			mapName := getAttr(n, "name")
+			if mapName == "" {
+				mapName = getAttr(n, "id")
+			}
+			areas := mapAreas(n)
+			if len(areas) > 0 {
+				extractedText = "\n"
+				lineCount++
+			}
+			for _, area := range areas {
+				text := area.alt
+				if text == "" {
+					text = area.href
+				}
+				label := text
+				if keepLink() {
+					extractedLinks = append(extractedLinks, LinkInfo{
+						Text:  text,
+						Href:  resolveURL(currentURL, area.href),
+						Line:  lineCount,
+						Map:   mapName,
+						Index: linkCount,
+					})
+					label = linkMarker(linkCount) + linkRegion(linkCount, text)
+				}
+				extractedText += "↳ " + label + "\n"
+				lineCount++
 			}
+			return extractedText, extractedLinks, nil
 		}
 
 		if n.Type == html.ElementNode && n.Data == "img" {
-			var src, alt string
+			var src, alt, srcset, useMap string
 			for _, attr := range n.Attr {
 				switch attr.Key {
 				case "src":
 					src = attr.Val
 				case "alt":
-					alt = attr.Val
+					alt = normalizeSpaces(attr.Val)
+				case "srcset":
+					srcset = attr.Val
+				case "usemap":
+					useMap = strings.TrimPrefix(attr.Val, "#")
 				}
 			}
-			
+
+			candidates := candidateURLs(parseSrcset(srcset, currentURL))
+			if src == "" && len(candidates) > 0 {
+				src, candidates = candidates[0], candidates[1:]
+			}
+			if n.Parent != nil && n.Parent.Type == html.ElementNode && n.Parent.Data == "picture" {
+				src, candidates = pictureSources(n.Parent, n, currentURL)
+			}
+
 			if src != "" {
 				resolvedSrc := resolveURL(currentURL, src)
-				extractedImages = append(extractedImages, ImageInfo{Src: resolvedSrc, Alt: alt})
+				if keepImage() {
+					extractedImages = append(extractedImages, ImageInfo{
+						Src:        resolvedSrc,
+						Alt:        alt,
+						Line:       lineCount,
+						Candidates: candidates,
+						UseMap:     useMap,
+					})
+				}
+				if htmlWhitespace() {
+					if alt != "" {
+						spaceBefore = false
+					}
+					return alt, extractedLinks, extractedImages
+				}
 				return alt + " ", extractedLinks, extractedImages
 			}
 		}
 
+		// html.Parse has already decoded entities in text nodes
+		data := n.Data
+		if n.Type == html.TextNode && config.EmojiShortcodes && preformatted == 0 {
+			data = replaceShortcodes(data)
+		}
+
+		if n.Type == html.TextNode && htmlWhitespace() && direction != "rtl" {
+			if preformatted > 0 {
+				lineCount += strings.Count(data, "\n")
+				spaceBefore = true
+				return normalizeSpaces(data), nil, nil
+			}
+			text := collapseWhitespace(data)
+			if spaceBefore {
+				text = strings.TrimPrefix(text, " ")
+			}
+			if text != "" {
+				spaceBefore = strings.HasSuffix(text, " ")
+			}
+			return normalizeSpaces(text), nil, nil
+		}
+
+		if n.Type == html.TextNode && preformatted > 0 && direction != "rtl" {
+			lineCount += strings.Count(data, "\n")
+			return normalizeSpaces(data), nil, nil
+		}
+
 		if n.Type == html.TextNode {
-			extractedText = strings.TrimSpace(n.Data)
+			extractedText = normalizeSpaces(strings.TrimSpace(data))
+			if extractedText != "" && direction == "rtl" {
+				block := formatRTL(extractedText, renderWidth)
+				lineCount += strings.Count(block, "\n")
+				return block, nil, nil
+			}
 			if extractedText != "" {
 				lineCount++
 				return extractedText + "\n", nil, nil
@@ -230,33 +996,192 @@ func extractContent(node *html.Node, currentURL string) (string, []LinkInfo, []I
 			return "", nil, nil
 		}
 
+		return extractChildren(n, extractedText, extractedLinks, extractedImages)
+	}
+
+	// extractChildren appends the content of n's children to what has
+	// been extracted for n itself.
+	extractChildren = func(n *html.Node, text string, links []LinkInfo, images []ImageInfo) (string, []LinkInfo, []ImageInfo) {
 		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if !htmlWhitespace() {
+				// Text nodes end their own lines, but links and images
+				// do not, so <br> and blocks make sure a line is ended
+				if isBlockElement(c) || isElement(c, "br") {
+					text = breakLine(text, &lineCount)
+				}
+				recordAnchor(c)
+				childText, childLinks, childImages := extractFunc(c, lineCount)
+				text += childText
+				if isElement(c, "p") {
+					text = endParagraph(text, &lineCount)
+				} else if isBlockElement(c) {
+					text = breakLine(text, &lineCount)
+				}
+				links = append(links, childLinks...)
+				images = append(images, childImages...)
+				continue
+			}
+
+			// Block elements sit on lines of their own; inline content
+			// flows on the current line.
+			if isBlockElement(c) {
+				text = breakLine(text, &lineCount)
+				spaceBefore = true
+			}
+			recordAnchor(c)
 			childText, childLinks, childImages := extractFunc(c, lineCount)
-			extractedText += childText
-			extractedLinks = append(extractedLinks, childLinks...)
-			extractedImages = append(extractedImages, childImages...)
+			if preformatted > 0 {
+				text += childText
+			} else {
+				text = joinInline(text, childText)
+			}
+			if isElement(c, "p") {
+				text = endParagraph(text, &lineCount)
+				spaceBefore = true
+			} else if isBlockElement(c) {
+				text = breakLine(text, &lineCount)
+				spaceBefore = true
+			}
+			links = append(links, childLinks...)
+			images = append(images, childImages...)
+		}
+
+		return text, links, images
+	}
+
+	text, links, images := extractFunc(node, 0)
+	text += overflowNote(droppedLinks, droppedImages)
+
+	// The running line count drifts where inline content shares a line,
+	// so links take their line from where their region ended up
+	setLinkLines(text, links)
+
+	// Headings know the line their text is on, below the blank line
+	// set before them
+	for _, heading := range headings {
+		if line, ok := anchors[heading.ID]; heading.ID != "" && (!ok || line == heading.Line-1) {
+			anchors[heading.ID] = heading.Line
+		}
+	}
+
+	return &Page{
+		Text:      text,
+		Links:     links,
+		Images:    images,
+		Tables:    tables,
+		Headings:  headings,
+		Anchors:   anchors,
+		Collapsed: collapsed,
+		Expanded:  opts.expand,
+	}
+}
+
+type mapArea struct {
+	href string
+	alt  string
+}
+
+// mapAreas returns the <area> elements with an href inside an image map,
+// using their alt text or else their title as the link text.
+func mapAreas(n *html.Node) []mapArea {
+	var areas []mapArea
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
 		}
+		if c.Data != "area" {
+			areas = append(areas, mapAreas(c)...)
+			continue
+		}
+		href := getAttr(c, "href")
+		if href == "" {
+			continue
+		}
+		alt := strings.TrimSpace(getAttr(c, "alt"))
+		if alt == "" {
+			alt = strings.TrimSpace(getAttr(c, "title"))
+		}
+		areas = append(areas, mapArea{href: href, alt: alt})
+	}
+	return areas
+}
+
+// formatAddress groups the contents of an <address> element into a single
+// indented, styled contact block set apart from the surrounding text.
+func formatAddress(contactText string) string {
+	var block strings.Builder
+	block.WriteString("\n")
+	for _, line := range strings.Split(contactText, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		block.WriteString(activeTheme.address + "│ " + line + "[-::-]\n")
+	}
+	block.WriteString("\n")
+	return block.String()
+}
+
+// overflowNote tells how many links and images were left out of the page
+// because of the configured limits.
+func overflowNote(links, images int) string {
+	var parts []string
+	if links > 0 {
+		parts = append(parts, fmt.Sprintf("+%d more links", links))
+	}
+	if images > 0 {
+		parts = append(parts, fmt.Sprintf("+%d more images", images))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n%s(%s not indexed)[-::-]\n", activeTheme.muted, strings.Join(parts, ", "))
+}
 
-		return extractedText, extractedLinks, extractedImages
+// formatTextarea renders the default content of a <textarea> verbatim,
+// keeping indentation and line breaks, inside a framed block.
+func formatTextarea(content string) string {
+	content = strings.TrimRight(content, "\n")
+
+	var block strings.Builder
+	block.WriteString("\n")
+	for _, line := range strings.Split(content, "\n") {
+		block.WriteString(activeTheme.muted + "│[-::-] " + strings.TrimRight(line, "\r") + "\n")
 	}
+	block.WriteString("\n")
+	return block.String()
+}
 
-	text, links, images = extractFunc(node, 0)
-	return text, links, images
+// renderOptions change how a document is rendered.
+type renderOptions struct {
+	// skip holds elements left out of the page, see quiet.go.
+	skip map[*html.Node]bool
+	// expand renders blocks that are normally collapsed, such as cookie
+	// consent banners, in full. See collapse.go.
+	expand bool
 }
 
-func renderHTML(htmlContent, currentURL string) (string, []LinkInfo, []ImageInfo, error) {
+func renderHTML(htmlContent, currentURL string) (*Page, error) {
+	return renderHTMLWith(htmlContent, currentURL, renderOptions{})
+}
+
+// renderHTMLWith is renderHTML with options.
+func renderHTMLWith(htmlContent, currentURL string, opts renderOptions) (*Page, error) {
 	doc, err := html.Parse(strings.NewReader(htmlContent))
 	if err != nil {
-		return "", nil, nil, fmt.Errorf("error parsing HTML: %v", err)
+		return nil, fmt.Errorf("error parsing HTML: %v", err)
 	}
+	return renderDocument(doc, htmlContent, currentURL, opts), nil
+}
 
-	var bodyText string
-	var bodyLinks []LinkInfo
-	var bodyImages []ImageInfo
+// renderDocument renders the parsed htmlContent according to opts.
+func renderDocument(doc *html.Node, htmlContent, pageURL string, opts renderOptions) *Page {
+	currentURL := documentBase(doc, pageURL)
+	page := &Page{}
 	var findBody func(*html.Node)
 	findBody = func(n *html.Node) {
 		if n.Type == html.ElementNode && n.Data == "body" {
-			bodyText, bodyLinks, bodyImages = extractContent(n, currentURL)
+			page = extractContent(n, currentURL, opts)
 			return
 		}
 		for c := n.FirstChild; c != nil; c = c.NextSibling {
@@ -264,106 +1189,226 @@ func renderHTML(htmlContent, currentURL string) (string, []LinkInfo, []ImageInfo
 		}
 	}
 	findBody(doc)
+	page.Title = extractTitle(doc)
+	page.Refresh, page.RefreshDelay = findRefresh(doc, currentURL)
+	// The canonical URL must share the page's origin, whatever the base
+	page.Canonical = findCanonical(doc, pageURL)
+	page.Next = findNextPage(doc, currentURL)
+	page.Search = findSearchForm(doc, currentURL)
+	page.Forms = findForms(doc, currentURL)
+	page.Source = htmlContent
+	page.Words = countWords(page.Text)
 
-	return bodyText, bodyLinks, bodyImages, nil
+	return page
 }
 
-func browseInteractive(initialURL string) error {
-	app := tview.NewApplication()
-	textView := tview.NewTextView().
-		SetDynamicColors(true).
-		SetRegions(true).
-		SetWordWrap(true).
-		SetTextStyle(tcell.StyleDefault.Background(tcell.ColorDefault).Foreground(tcell.ColorDefault))
-	
-	var currentURL string
-	var links []LinkInfo
-
-	textView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		switch event.Key() {
-		case tcell.KeyEscape:
-			app.Stop()
-			return nil
+// findCanonical returns the resolved <link rel="canonical"> URL of doc, or
+// an empty string when it is missing, malformed or points to another origin.
+func findCanonical(doc *html.Node, currentURL string) string {
+	var href string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if href != "" {
+			return
 		}
-		return event
-	})
-
-	textView.SetMouseCapture(func(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
-		if action == tview.MouseLeftClick {
-			_, y := event.Position()
-			
-			// Adjust for text view's internal scrolling
-			_, scrollOffset := textView.GetScrollOffset()
-			y += scrollOffset
-
-			// Check if click is on a link
-			for _, link := range links {
-				if link.Line == y {
-					currentURL = link.Href
-					go func() {
-						htmlContent, err := fetchURL(currentURL)
-						if err != nil {
-							textView.SetText(fmt.Sprintf("Error fetching URL: %v", err))
-							return
-						}
-
-						renderedText, newLinks, _, err := renderHTML(htmlContent, currentURL)
-						if err != nil {
-							textView.SetText(fmt.Sprintf("Error rendering HTML: %v", err))
-							return
-						}
-
-						app.QueueUpdateDraw(func() {
-							textView.SetText(renderedText)
-							links = newLinks
-						})
-					}()
-					break
+		if n.Type == html.ElementNode && n.Data == "link" {
+			var rel, linkHref string
+			for _, attr := range n.Attr {
+				switch attr.Key {
+				case "rel":
+					rel = attr.Val
+				case "href":
+					linkHref = attr.Val
+				}
+			}
+			for _, value := range strings.Fields(rel) {
+				if strings.EqualFold(value, "canonical") && linkHref != "" {
+					href = linkHref
 				}
 			}
+			return
 		}
-		return action, event
-	})
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
 
-	// Initial page load
-	go func() {
-		currentURL = initialURL
-		htmlContent, err := fetchURL(currentURL)
-		if err != nil {
-			textView.SetText(fmt.Sprintf("Error fetching URL: %v", err))
-			return
+	if href == "" {
+		return ""
+	}
+
+	canonical, err := url.Parse(resolveURL(currentURL, href))
+	if err != nil {
+		return ""
+	}
+	current, err := url.Parse(currentURL)
+	if err != nil {
+		return ""
+	}
+	if canonical.Scheme != current.Scheme || !strings.EqualFold(canonical.Host, current.Host) {
+		return ""
+	}
+	canonical.Fragment = ""
+	return canonical.String()
+}
+
+func main() {
+	cacheMem := flag.Int("cache-mem", 64, "memory budget in MiB for cached pages and images")
+	colors := flag.String("colors", "auto", "force a color level: auto, none, 8, 256 or truecolor")
+	maxDownloads := flag.Int("max-downloads", 4, "maximum number of concurrent downloads")
+	maxDownloadMB := flag.Int("max-download-size", 50, "maximum size in MiB of a single download")
+	archiveFallback := flag.Bool("archive-fallback", false, "retry pages that 404 or fail to load through a web archive")
+	whitespace := flag.String("whitespace", "", "text layout: lines (one per text node) or html (HTML whitespace rules; overrides config)")
+	truncate := flag.Int("truncate", -1, "display only this many lines of long pages until L is pressed, 0 to disable (overrides config)")
+	emoji := flag.Bool("emoji-shortcodes", false, "show :shortcode: emoji in page text as glyphs")
+	quiet := flag.Bool("quiet", false, "hide boilerplate repeated across pages of the same site")
+	showConsent := flag.Bool("show-consent", false, "do not collapse cookie consent banners")
+	scrollMode := flag.String("scroll", "", "scrolling behavior: line or smooth (overrides config)")
+	timeout := flag.Duration("timeout", 30*time.Second, "overall time limit for loading a page, including retries")
+	attemptTimeout := flag.Duration("attempt-timeout", 10*time.Second, "time limit for connecting and receiving the first byte of a single attempt")
+	retries := flag.Int("retries", 2, "number of times to retry an attempt that timed out")
+	warcFile := flag.String("warc", "", "replay pages from this WARC file (.warc or .warc.gz) before using the network")
+	warcOnlyFlag := flag.Bool("warc-only", false, "with -warc, never use the network for pages missing from the archive")
+	agent := flag.String("user-agent", userAgent, "User-Agent header sent with every request")
+	lang := flag.String("lang", localeLanguage(os.Getenv("LANG")), "Accept-Language header sent with every request, taken from $LANG by default; empty to send none")
+	colorImages := flag.Bool("color-images", false, "tint ASCII art images with the colors of the image")
+	sixel := flag.Bool("sixel", false, "show images as sixel graphics when the terminal supports them")
+	dump := flag.Bool("dump", false, "print the rendered page text and a numbered list of its links, then exit")
+	format := flag.String("format", "", "print the page in this format and exit: text or markdown")
+	output := flag.String("o", "", "write -dump, -format, -links, -images and -annotate output to this file instead of stdout")
+	proxyFlag := flag.String("proxy", "", "send all requests through this proxy, e.g. http://127.0.0.1:8118 or socks5://127.0.0.1:9050 (overrides HTTP_PROXY)")
+	noCookies := flag.Bool("no-cookies", false, "do not keep cookies between requests")
+	flag.IntVar(&asciiWidth, "ascii-width", 0, "width in characters of ASCII art images (default: terminal width)")
+	invert := flag.Bool("invert", false, "reverse the ASCII art character ramp, for light terminals")
+	rampChars := flag.String("ascii-chars", "", "characters for ASCII art images, from darkest to brightest")
+	annotate := flag.Bool("annotate", false, "print the page text with numbered link markers and a reference table, then exit")
+	linksFlag := flag.Bool("links", false, "print the page's links, one URL and its text per line, then exit")
+	imagesFlag := flag.Bool("images", false, "print the page's images, one URL and its alt text per line, then exit")
+	keepDownloads := flag.Bool("keep-downloads", false, "keep downloaded files instead of deleting them on exit")
+	flag.StringVar(&downloadDir, "download-dir", defaultDownloadDir, "directory for downloaded files; implies -keep-downloads unless it is the default")
+	flag.Parse()
+
+	// The directory depends on the flags, so it is created here rather
+	// than in init. A directory of the user's choosing is never emptied.
+	if err := os.MkdirAll(downloadDir, 0755); err != nil {
+		fmt.Printf("Error creating download dir: %v\n", err)
+		os.Exit(1)
+	}
+	if !*keepDownloads && downloadDir == defaultDownloadDir {
+		defer cleanupDownloads()
+	}
+
+	if flag.NArg() < 1 {
+		fmt.Println("Usage: go run main.go [flags] <url>")
+		os.Exit(1)
+	}
+
+	sessionCache.SetBudget(int64(*cacheMem) << 20)
+	setDownloadLimits(*maxDownloads, int64(*maxDownloadMB)<<20)
+	userAgent = *agent
+	acceptLanguage = *lang
+	activeRamp = asciiRamp(*rampChars, *invert)
+	if *proxyFlag != "" {
+		parsed, err := url.Parse(*proxyFlag)
+		if err != nil || parsed.Host == "" {
+			fmt.Printf("Invalid proxy URL: %s\n", *proxyFlag)
+			os.Exit(1)
+		}
+		proxyURL = parsed
+	}
+	if *noCookies {
+		cookieJar = nil
+	}
+	configureTimeouts(*timeout, *attemptTimeout, *retries)
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+	}
+	config = cfg
+	if *archiveFallback {
+		config.ArchiveFallback = true
+	}
+	if *whitespace != "" {
+		config.Whitespace = *whitespace
+	}
+	if *truncate >= 0 {
+		config.TruncateLines = *truncate
+	}
+	if *emoji {
+		config.EmojiShortcodes = true
+	}
+	if *quiet {
+		config.QuietMode = true
+	}
+	if *showConsent {
+		config.HideConsentBanners = false
+	}
+	if *scrollMode != "" {
+		config.ScrollMode = *scrollMode
+	}
+	if *colorImages {
+		config.ColorImages = true
+	}
+
+	if *sixel {
+		sixelEnabled = terminalSupportsSixel()
+		if !sixelEnabled {
+			fmt.Println("Terminal does not support sixel graphics, showing images as ASCII art")
 		}
+	}
 
-		renderedText, newLinks, _, err := renderHTML(htmlContent, currentURL)
+	if *warcFile != "" {
+		warcArchive, err = loadWARC(*warcFile)
 		if err != nil {
-			textView.SetText(fmt.Sprintf("Error rendering HTML: %v", err))
-			return
+			fmt.Println(err)
+			os.Exit(1)
 		}
+		warcOnly = *warcOnlyFlag
+	}
 
-		app.QueueUpdateDraw(func() {
-			textView.SetText(renderedText)
-			links = newLinks
-		})
-	}()
+	bookmarks, err = loadBookmarks()
+	if err != nil {
+		fmt.Println(err)
+	}
+	if err := loadHistory(); err != nil {
+		fmt.Println(err)
+	}
 
-	if err := app.SetRoot(textView, true).EnableMouse(true).Run(); err != nil {
-		return err
+	level, err := parseColorLevel(*colors)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
 	}
 
-	return nil
-}
+	url := normalizeURL(flag.Arg(0))
 
-func main() {
-	defer cleanupDownloads()
+	if *dump || *format != "" {
+		if err := printPage(url, *format, *output); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	if len(os.Args) < 2 {
-		fmt.Println("Usage: go run main.go <url>")
-		os.Exit(1)
+	if *linksFlag || *imagesFlag {
+		if err := printLists(url, *linksFlag, *imagesFlag, *output); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *annotate {
+		if err := printAnnotated(url, *output); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
 	}
 
-	url := os.Args[1]
-	
-	err := browseInteractive(url)
+	err = browseInteractive(url, level)
 	if err != nil {
 		fmt.Printf("Error browsing: %v\n", err)
 		os.Exit(1)
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// renderSeeds are malformed and pathological documents the fuzzer starts
+// from.
+var renderSeeds = []string{
+	"",
+	"<html><body><p>Hello <a href=/x>world</a></p></body></html>",
+	strings.Repeat("<div>", maxRenderDepth+10) + "deep",
+	strings.Repeat("<ul><li>", maxRenderDepth/2) + "list",
+	strings.Repeat("<b><a href=x>", 100) + "links",
+	"<table><tr><td><table><tr><td>cell</table><td colspan=-1 rowspan=99999>x",
+	"<a href>no value</a><img src><img srcset=', , 2x'><a href='%zz'>bad escape</a>",
+	"<pre><code>\n\n</pre></code><textarea>unclosed",
+	"<dialog><h1 id=>title</h1><dl><dd><dt></dl>",
+	"<picture><source srcset><img></picture><svg><title>t</title></svg>",
+	"<p dir=rtl>שלום <b>עולם</b></p><bdo dir=ltr>x</bdo>",
+	"<select><option><option selected></select><form><input type=radio name=r>",
+	"<base href='::'><a href='#frag'>frag</a><meta http-equiv=refresh content='0;url='>",
+	"\x00<\x00a\x00>\xff\xfe",
+}
+
+func FuzzExtractContent(f *testing.F) {
+	for _, seed := range renderSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		doc, err := html.Parse(strings.NewReader(input))
+		if err != nil {
+			return
+		}
+		extractContent(doc, "https://example.com/dir/page.html", renderOptions{})
+	})
+}
+
+func TestExtractContentStopsAtDepthCap(t *testing.T) {
+	shallow := strings.Repeat("<div>", maxRenderDepth/2) + "reached" + strings.Repeat("</div>", maxRenderDepth/2)
+	deep := strings.Repeat("<span>", maxRenderDepth*4) + "too deep"
+	page, err := renderHTML(shallow+deep, "https://example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(page.Text, "reached") {
+		t.Errorf("text nested below the cap is missing: %q", page.Text)
+	}
+	if strings.Contains(page.Text, "too deep") {
+		t.Errorf("text nested beyond the cap was rendered")
+	}
+}
+
+func TestResolveURL(t *testing.T) {
+	const page = "https://example.com/docs/guide.html?v=2#intro"
+	tests := []struct {
+		name, href, want string
+	}{
+		{"relative", "setup.html", "https://example.com/docs/setup.html"},
+		{"parent", "../index.html", "https://example.com/index.html"},
+		{"root-relative", "/about", "https://example.com/about"},
+		{"protocol-relative", "//cdn.example.net/x.png", "https://cdn.example.net/x.png"},
+		{"fragment-only", "#section", "https://example.com/docs/guide.html?v=2#section"},
+		{"query-only", "?v=3", "https://example.com/docs/guide.html?v=3"},
+		{"absolute", "http://other.example/a", "http://other.example/a"},
+		{"surrounding whitespace", "  setup.html\n", "https://example.com/docs/setup.html"},
+		{"embedded newline", "set\nup.html", "https://example.com/docs/setup.html"},
+		{"stray percent", "100%.html", "https://example.com/docs/100%25.html"},
+		{"mailto", "mailto:someone@example.com", "mailto:someone@example.com"},
+		{"javascript", "javascript:void(0)", "javascript:void(0)"},
+		{"tel", "tel:+15551234", "tel:+15551234"},
+		{"data URI", "data:image/png;base64,AAAA", "data:image/png;base64,AAAA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveURL(page, tt.href); got != tt.want {
+				t.Errorf("resolveURL(%q) = %q, want %q", tt.href, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSamePageFragment(t *testing.T) {
+	const page = "https://example.com/docs/guide.html?v=2#intro"
+	tests := []struct {
+		href, fragment string
+		same           bool
+	}{
+		{"#section", "section", true},
+		{"guide.html?v=2#section", "section", true},
+		{"guide.html#section", "", false},
+		{"other.html#section", "", false},
+		{"guide.html?v=2", "", false},
+	}
+	for _, tt := range tests {
+		fragment, same := samePageFragment(page, tt.href)
+		if same != tt.same || same && fragment != tt.fragment {
+			t.Errorf("samePageFragment(%q) = %q, %v; want %q, %v", tt.href, fragment, same, tt.fragment, tt.same)
+		}
+	}
+}
+
+func TestBaseHrefResolvesLinks(t *testing.T) {
+	tests := []struct {
+		name, head, want string
+	}{
+		{"no base", "", "https://example.com/docs/setup.html"},
+		{"absolute base", `<base href="https://static.example.org/v1/">`, "https://static.example.org/v1/setup.html"},
+		{"relative base", `<base href="/archive/">`, "https://example.com/archive/setup.html"},
+		{"base without href", `<base target="_blank"><base href="/second/">`, "https://example.com/second/setup.html"},
+		{"javascript base", `<base href="javascript:alert(1)">`, "https://example.com/docs/setup.html"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := "<html><head>" + tt.head + `</head><body><a href="setup.html">Setup</a></body></html>`
+			page, err := renderHTML(doc, "https://example.com/docs/guide.html")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(page.Links) != 1 || page.Links[0].Href != tt.want {
+				t.Errorf("links = %+v, want one to %s", page.Links, tt.want)
+			}
+		})
+	}
+}
+
+// renderPage renders doc as the page at https://example.com/ with the
+// given whitespace mode.
+func renderPage(t *testing.T, whitespace, doc string) *Page {
+	t.Helper()
+	defer func(before string) { config.Whitespace = before }(config.Whitespace)
+	config.Whitespace = whitespace
+	page, err := renderHTML(doc, "https://example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return page
+}
+
+func TestAddressBlock(t *testing.T) {
+	doc := `<p>Intro</p><address>Jane Doe<br>
+		<a href="mailto:jane@example.com">jane@example.com</a><br>
+		Phone: <a href="tel:+15551234">555 1234</a></address><p>After</p>`
+	for _, whitespace := range []string{"lines", "html"} {
+		t.Run(whitespace, func(t *testing.T) {
+			page := renderPage(t, whitespace, doc)
+			lines := strings.Split(page.Text, "\n")
+
+			// The contact lines form one styled block set off by blank lines
+			var block []string
+			start := -1
+			for i, line := range lines {
+				if strings.HasPrefix(line, activeTheme.address+"│ ") {
+					if start < 0 {
+						start = i
+					}
+					block = append(block, stripTags(line))
+				}
+			}
+			if start < 1 || lines[start-1] != "" || lines[start+len(block)] != "" {
+				t.Fatalf("address block is not set off by blank lines: %q", page.Text)
+			}
+			if got := strings.Join(block, "\n"); !strings.Contains(got, "│ Jane Doe\n│ [1] jane@example.com\n│ Phone:") {
+				t.Errorf("address block = %q", got)
+			}
+
+			if len(page.Links) != 2 || page.Links[0].Href != "mailto:jane@example.com" || page.Links[1].Href != "tel:+15551234" {
+				t.Fatalf("links = %+v", page.Links)
+			}
+			for _, link := range page.Links {
+				if !strings.Contains(lines[link.Line], regionTag(link)) {
+					t.Errorf("link %q is not on its Line %d", link.Text, link.Line)
+				}
+			}
+		})
+	}
+}
+
+func TestMultiLineTextarea(t *testing.T) {
+	doc := "<p>Before</p><textarea>\nfunc main() {\n    fmt.Println(\"a  b\")\n\n}\n</textarea><p>After <a href=x>x</a></p>"
+	want := "│ func main() {\n│     fmt.Println(\"a  b\")\n│ \n│ }\n"
+	for _, whitespace := range []string{"lines", "html"} {
+		t.Run(whitespace, func(t *testing.T) {
+			page := renderPage(t, whitespace, doc)
+			if text := stripTags(page.Text); !strings.Contains(text, "\n"+want+"\n") {
+				t.Errorf("textarea content not kept verbatim in a block:\n%s", text)
+			}
+			link := page.Links[0]
+			if !strings.Contains(strings.Split(page.Text, "\n")[link.Line], regionTag(link)) {
+				t.Errorf("link after the textarea has Line %d, which does not contain it", link.Line)
+			}
+		})
+	}
+}
+
+// linkPage returns a page with links links and images images.
+func linkPage(links, images int) string {
+	var doc strings.Builder
+	doc.WriteString("<ul>")
+	for i := 0; i < links; i++ {
+		fmt.Fprintf(&doc, `<li><a href="/page/%d">Page %d</a></li>`, i, i)
+	}
+	doc.WriteString("</ul>")
+	for i := 0; i < images; i++ {
+		fmt.Fprintf(&doc, `<img src="/img/%d.png" alt="Image %d">`, i, i)
+	}
+	return doc.String()
+}
+
+func TestLinkAndImageCaps(t *testing.T) {
+	defer func(links, images int) { config.MaxLinks, config.MaxImages = links, images }(config.MaxLinks, config.MaxImages)
+	config.MaxLinks, config.MaxImages = 1000, 100
+
+	page := renderPage(t, "html", linkPage(5000, 300))
+	if len(page.Links) != 1000 || len(page.Images) != 100 {
+		t.Errorf("collected %d links and %d images, want 1000 and 100", len(page.Links), len(page.Images))
+	}
+	if last := page.Links[len(page.Links)-1]; last.Href != "https://example.com/page/999" || last.Index != 1000 {
+		t.Errorf("last collected link = %+v", last)
+	}
+	text := stripTags(page.Text)
+	if !strings.HasSuffix(text, "(+4000 more links, +200 more images not indexed)\n") {
+		t.Errorf("page does not end with the overflow marker: %q", text[max(0, len(text)-100):])
+	}
+	// Links past the cap are still shown, just not numbered
+	if !strings.Contains(text, "Page 4999") || strings.Contains(text, "[1001]") {
+		t.Errorf("links past the cap are missing or numbered")
+	}
+}
+
+func TestDefaultLinkCapKeepsLargePages(t *testing.T) {
+	page := renderPage(t, "html", linkPage(3000, 0))
+	if len(page.Links) != 3000 || strings.Contains(page.Text, "not indexed") {
+		t.Errorf("default cap dropped links from a 3000-link page: %d collected", len(page.Links))
+	}
+}
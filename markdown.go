@@ -0,0 +1,266 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// markdownEscaper escapes characters that would otherwise start Markdown
+// formatting in page text.
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`)
+
+// skippedElement reports whether n is never rendered, by any renderer.
+func skippedElement(n *html.Node) bool {
+	return n.Type == html.ElementNode && (n.Data == "script" || n.Data == "style" || tagDenied(n.Data))
+}
+
+// renderMarkdown converts the body of the HTML document htmlContent to
+// Markdown, resolving link and image URLs against baseURL.
+func renderMarkdown(htmlContent, baseURL string) (string, error) {
+	doc, err := html.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		return "", fmt.Errorf("error parsing HTML: %v", err)
+	}
+	baseURL = documentBase(doc, baseURL)
+
+	body := doc
+	var findBody func(*html.Node)
+	findBody = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "body" {
+			body = n
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			findBody(c)
+		}
+	}
+	findBody(doc)
+
+	return strings.TrimSpace(markdownBlocks(body, baseURL)) + "\n", nil
+}
+
+// markdownBlocks renders the children of n as Markdown blocks separated by
+// blank lines. Runs of inline content become paragraphs.
+func markdownBlocks(n *html.Node, baseURL string) string {
+	var out, inline strings.Builder
+	flush := func() {
+		var lines []string
+		for _, line := range strings.Split(inline.String(), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				lines = append(lines, line)
+			}
+		}
+		if len(lines) > 0 {
+			out.WriteString(strings.Join(lines, "\n") + "\n\n")
+		}
+		inline.Reset()
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if skippedElement(c) {
+			continue
+		}
+		if !isBlockElement(c) && (c.Type != html.ElementNode || c.Data != "blockquote") {
+			inline.WriteString(markdownInline(c, baseURL))
+			continue
+		}
+		flush()
+		out.WriteString(markdownBlock(c, baseURL))
+	}
+	flush()
+	return out.String()
+}
+
+// markdownBlock renders a single block element.
+func markdownBlock(n *html.Node, baseURL string) string {
+	if level := headingLevel(n); level > 0 {
+		text := strings.TrimSpace(strings.ReplaceAll(markdownInline(n, baseURL), "\\\n", " "))
+		if text == "" {
+			return ""
+		}
+		return strings.Repeat("#", level) + " " + text + "\n\n"
+	}
+
+	switch n.Data {
+	case "ul", "ol":
+		return markdownList(n, baseURL, "") + "\n"
+	case "pre":
+		return "```\n" + strings.Trim(textContent(n), "\n") + "\n```\n\n"
+	case "hr":
+		return "---\n\n"
+	case "blockquote":
+		inner := strings.TrimSpace(markdownBlocks(n, baseURL))
+		if inner == "" {
+			return ""
+		}
+		lines := strings.Split(inner, "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight("> "+line, " ")
+		}
+		return strings.Join(lines, "\n") + "\n\n"
+	case "table":
+		return markdownTable(n)
+	}
+	return markdownBlocks(n, baseURL)
+}
+
+// markdownInline renders n as inline Markdown with whitespace collapsed.
+func markdownInline(n *html.Node, baseURL string) string {
+	if n.Type == html.TextNode {
+		return markdownEscaper.Replace(collapseWhitespace(n.Data))
+	}
+	if skippedElement(n) {
+		return ""
+	}
+
+	var inner strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		inner.WriteString(markdownInline(c, baseURL))
+	}
+	text := inner.String()
+	if n.Type != html.ElementNode {
+		return text
+	}
+
+	switch n.Data {
+	case "a":
+		href := getAttr(n, "href")
+		label := strings.TrimSpace(text)
+		if href == "" || label == "" {
+			return text
+		}
+		return "[" + label + "](" + resolveURL(baseURL, href) + ")"
+	case "img":
+		src := getAttr(n, "src")
+		if src == "" {
+			return ""
+		}
+		return "![" + markdownEscaper.Replace(getAttr(n, "alt")) + "](" + resolveURL(baseURL, src) + ")"
+	case "strong", "b":
+		return wrapInline(text, "**")
+	case "em", "i":
+		return wrapInline(text, "*")
+	case "code":
+		if code := textContent(n); strings.TrimSpace(code) != "" {
+			return "`" + code + "`"
+		}
+	case "br":
+		return "\\\n"
+	}
+	if isBlockElement(n) {
+		return "\n" + text + "\n"
+	}
+	return text
+}
+
+// wrapInline surrounds the text of text with marker, keeping its edge
+// spaces outside so the emphasis stays valid Markdown.
+func wrapInline(text, marker string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	return keepEdgeSpaces(text, marker+trimmed+marker)
+}
+
+// markdownList renders a list with each nested list indented below its
+// item by indent plus the width of the item's marker.
+func markdownList(n *html.Node, baseURL, indent string) string {
+	state := newListState(n)
+	var out strings.Builder
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != html.ElementNode || li.Data != "li" {
+			continue
+		}
+
+		marker := "- "
+		if state.ordered {
+			marker = strconv.Itoa(state.next) + ". "
+			state.next++
+		}
+
+		var text, nested strings.Builder
+		for c := li.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && (c.Data == "ul" || c.Data == "ol") {
+				nested.WriteString(markdownList(c, baseURL, indent+strings.Repeat(" ", len(marker))))
+				continue
+			}
+			text.WriteString(markdownInline(c, baseURL))
+		}
+
+		item := strings.Join(strings.Fields(strings.ReplaceAll(text.String(), "\\\n", " ")), " ")
+		out.WriteString(indent + marker + item + "\n")
+		out.WriteString(nested.String())
+	}
+	return out.String()
+}
+
+// markdownTable renders a table as a Markdown pipe table. The first row is
+// used as the header, and short rows are padded with empty cells.
+func markdownTable(n *html.Node) string {
+	rows, _ := tableRows(n)
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	if columns == 0 {
+		return ""
+	}
+
+	var out strings.Builder
+	for r, row := range rows {
+		cells := make([]string, columns)
+		for i := range cells {
+			if i < len(row) {
+				cells[i] = strings.ReplaceAll(markdownEscaper.Replace(row[i]), "|", `\|`)
+			}
+		}
+		out.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		if r == 0 {
+			out.WriteString("|" + strings.Repeat(" --- |", columns) + "\n")
+		}
+	}
+	return out.String() + "\n"
+}
+
+// textContent returns the text below n exactly as written.
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var out strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		out.WriteString(textContent(c))
+	}
+	return out.String()
+}
+
+// saveMarkdown writes the current page as Markdown into exportDir.
+func (b *browser) saveMarkdown() {
+	if b.source == "" {
+		b.setStatus("No page loaded")
+		return
+	}
+
+	markdown, err := renderMarkdown(b.source, b.currentURL)
+	if err != nil {
+		b.setStatus(err.Error())
+		return
+	}
+	if err := os.MkdirAll(exportDir, 0755); err != nil {
+		b.setStatus(fmt.Sprintf("error creating export dir: %v", err))
+		return
+	}
+	filename := filepath.Join(exportDir, fmt.Sprintf("page_%s.md", time.Now().Format("20060102_150405")))
+	if err := os.WriteFile(filename, []byte(markdown), 0644); err != nil {
+		b.setStatus(fmt.Sprintf("error writing markdown: %v", err))
+		return
+	}
+	b.setStatus("Saved page as Markdown to " + filename)
+}
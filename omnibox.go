@@ -0,0 +1,147 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rivo/tview"
+)
+
+// maxSuggestions is how many entries the omnibox drop-down shows.
+const maxSuggestions = 10
+
+type visit struct {
+	URL  string
+	Time time.Time
+}
+
+// suggestion is an omnibox autocomplete entry pointing at URL.
+type suggestion struct {
+	Label string
+	URL   string
+	score float64
+}
+
+func (b *browser) recordVisit(pageURL string) {
+	b.visits = append(b.visits, visit{URL: pageURL, Time: time.Now()})
+}
+
+// matchScore rates how well query matches an entry's text and URL: a prefix
+// of the text beats a prefix of one of its words, which beats a substring
+// anywhere. Zero means no match.
+func matchScore(query, text, entryURL string) float64 {
+	text = strings.ToLower(text)
+	entryURL = strings.ToLower(entryURL)
+
+	var score float64
+	switch {
+	case strings.HasPrefix(text, query):
+		score = 3
+	case strings.Contains(text, " "+query):
+		score = 2
+	case strings.Contains(text, query):
+		score = 1
+	}
+
+	host := strings.TrimPrefix(strings.TrimPrefix(entryURL, "https://"), "http://")
+	switch {
+	case strings.HasPrefix(host, query) || strings.HasPrefix(host, "www."+query):
+		score += 1.5
+	case strings.Contains(entryURL, query):
+		score += 0.5
+	}
+	return score
+}
+
+// suggest ranks the current page's links and the pages visited this session
+// against query. Recently visited pages get a bonus that fades over hours.
+func (b *browser) suggest(query string) []suggestion {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil
+	}
+
+	best := make(map[string]suggestion)
+	add := func(s suggestion) {
+		if s.score <= 0 {
+			return
+		}
+		if existing, ok := best[s.URL]; !ok || s.score > existing.score {
+			best[s.URL] = s
+		}
+	}
+
+	for _, link := range b.links {
+		add(suggestion{
+			Label: link.Text + " — " + link.Href,
+			URL:   link.Href,
+			score: matchScore(query, link.Text, link.Href),
+		})
+	}
+
+	for _, bookmark := range bookmarks {
+		score := matchScore(query, bookmark.Title, bookmark.URL)
+		if score > 0 {
+			// Bookmarks outrank links and visits that match as well
+			score++
+		}
+		add(suggestion{
+			Label: "★ " + bookmark.Title + " — " + bookmark.URL,
+			URL:   bookmark.URL,
+			score: score,
+		})
+	}
+
+	now := time.Now()
+	for _, v := range b.visits {
+		score := matchScore(query, v.URL, v.URL)
+		if score > 0 {
+			score += 1 / (1 + now.Sub(v.Time).Hours())
+		}
+		add(suggestion{Label: v.URL, URL: v.URL, score: score})
+	}
+
+	suggestions := make([]suggestion, 0, len(best))
+	for _, s := range best {
+		suggestions = append(suggestions, s)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].score != suggestions[j].score {
+			return suggestions[i].score > suggestions[j].score
+		}
+		return suggestions[i].Label < suggestions[j].Label
+	})
+	if len(suggestions) > maxSuggestions {
+		suggestions = suggestions[:maxSuggestions]
+	}
+	return suggestions
+}
+
+// enableOmniboxAutocomplete offers suggestions in the command line while the
+// input does not start with a command, and navigates to a suggestion when it
+// is selected with Enter or Tab.
+func (b *browser) enableOmniboxAutocomplete() {
+	var current []suggestion
+	b.promptField.SetAutocompleteFunc(func(text string) []string {
+		name, _, _ := strings.Cut(text, " ")
+		if _, ok := commands[name]; ok {
+			return nil
+		}
+
+		current = b.suggest(text)
+		entries := make([]string, len(current))
+		for i, s := range current {
+			entries[i] = tview.Escape(s.Label)
+		}
+		return entries
+	})
+	b.promptField.SetAutocompletedFunc(func(text string, index, source int) bool {
+		if source == tview.AutocompletedNavigate || index >= len(current) {
+			return false
+		}
+		b.closePrompt()
+		b.openURL(current[index].URL)
+		return true
+	})
+}
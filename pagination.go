@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rivo/tview"
+	"golang.org/x/net/html"
+)
+
+// nextLinkTexts are link labels that commonly point to the following page
+// of an article or result list, compared after trimming arrows.
+var nextLinkTexts = map[string]bool{
+	"next":          true,
+	"next page":     true,
+	"older posts":   true,
+	"older entries": true,
+	"more results":  true,
+}
+
+// findNextPage returns the URL of the page following doc: a rel="next"
+// <link> or <a>, or failing that an <a> whose text reads like "Next". It
+// returns an empty string when none is found.
+func findNextPage(doc *html.Node, currentURL string) string {
+	var relNext, textNext string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if relNext != "" {
+			return
+		}
+		if n.Type == html.ElementNode && (n.Data == "link" || n.Data == "a") {
+			href := getAttr(n, "href")
+			if href != "" {
+				for _, value := range strings.Fields(getAttr(n, "rel")) {
+					if strings.EqualFold(value, "next") {
+						relNext = href
+						return
+					}
+				}
+				if n.Data == "a" && textNext == "" {
+					text := strings.ToLower(strings.Trim(nodeText(n), " »›→>"))
+					if nextLinkTexts[text] {
+						textNext = href
+					}
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	href := relNext
+	if href == "" {
+		href = textNext
+	}
+	if href == "" || strings.HasPrefix(href, "#") {
+		return ""
+	}
+
+	resolved := resolveURL(currentURL, href)
+	if resolved == currentURL || !strings.HasPrefix(resolved, "http") {
+		return ""
+	}
+	return resolved
+}
+
+// appendPage returns base with next's content added below it, shifting
+// the line numbers of everything next references.
+func appendPage(base, next *Page, nextURL string) *Page {
+	separator := fmt.Sprintf("\n%s──── %s ────[-::-]\n\n", activeTheme.muted, tview.Escape(nextURL))
+	text := base.Text
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	text += separator
+	offset := strings.Count(text, "\n")
+
+	// Links on the next page are numbered on from the current ones
+	shift := 0
+	if len(base.Links) > 0 {
+		shift = base.Links[len(base.Links)-1].Index
+	}
+
+	merged := &Page{
+		Text:      text + renumberLinks(next.Text, shift),
+		Links:     append([]LinkInfo(nil), base.Links...),
+		Images:    append([]ImageInfo(nil), base.Images...),
+		Tables:    append([]TableInfo(nil), base.Tables...),
+		Headings:  append([]HeadingInfo(nil), base.Headings...),
+		Canonical: base.Canonical,
+		Source:    base.Source,
+		Next:      next.Next,
+		Search:    base.Search,
+		Forms:     base.Forms,
+		Title:     base.Title,
+		Status:    base.Status,
+		Header:    base.Header,
+		Words:     base.Words + next.Words,
+		Collapsed: base.Collapsed + next.Collapsed,
+		Expanded:  base.Expanded,
+	}
+	for _, link := range next.Links {
+		link.Line += offset
+		link.Index += shift
+		merged.Links = append(merged.Links, link)
+	}
+	for _, img := range next.Images {
+		img.Line += offset
+		merged.Images = append(merged.Images, img)
+	}
+	for _, table := range next.Tables {
+		table.Line += offset
+		merged.Tables = append(merged.Tables, table)
+	}
+	for _, heading := range next.Headings {
+		heading.Line += offset
+		merged.Headings = append(merged.Headings, heading)
+	}
+	merged.Anchors = make(map[string]int, len(base.Anchors)+len(next.Anchors))
+	for id, line := range next.Anchors {
+		merged.Anchors[id] = line + offset
+	}
+	for id, line := range base.Anchors {
+		merged.Anchors[id] = line
+	}
+	return merged
+}
+
+// currentPage reassembles the displayed page from the browser's state.
+func (b *browser) currentPage() *Page {
+	return &Page{
+		Text:     b.text,
+		Links:    b.links,
+		Images:   b.images,
+		Tables:   b.tables,
+		Headings: b.headings,
+		Anchors:  b.anchors,
+		Words:    b.words,
+		Source:   b.source,
+		Next:     b.nextURL,
+		Search:   b.search,
+		Forms:    b.forms,
+		Title:    b.title,
+		Status:   b.responseStatus,
+		Header:   b.responseHeader,
+	}
+}
+
+// appendedNext is a next page appended to the current view, kept so the
+// view can be rendered again. base is the URL it was rendered at, which
+// differs from url after a redirect.
+type appendedNext struct {
+	url  string
+	base string
+	page *Page
+}
+
+// appendNextPage loads the next page and adds it below the current view.
+// URLs already shown in this view are not loaded again, so pagination that
+// cycles back does not repeat forever.
+func (b *browser) appendNextPage() {
+	nextURL := b.nextURL
+	if nextURL == "" {
+		b.setStatus("No next page")
+		return
+	}
+	if b.loadedPages[nextURL] {
+		b.setStatus("Next page already loaded: " + nextURL)
+		return
+	}
+	b.loadedPages[nextURL] = true
+	b.setStatus("Loading " + nextURL)
+
+	go func() {
+		renderURL := nextURL
+		next, ok := getCachedPage(nextURL)
+		if !ok {
+			htmlContent, chain, err := fetchURLRedirects(nextURL)
+			if err != nil {
+				b.app.QueueUpdateDraw(func() {
+					delete(b.loadedPages, nextURL)
+					b.setStatus(fmt.Sprintf("Error fetching next page: %v", err))
+				})
+				return
+			}
+			renderURL = chain[len(chain)-1]
+			next, err = renderHTML(htmlContent, renderURL)
+			if err != nil {
+				b.app.QueueUpdateDraw(func() {
+					delete(b.loadedPages, nextURL)
+					b.setStatus(fmt.Sprintf("Error rendering next page: %v", err))
+				})
+				return
+			}
+			putCachedPage(nextURL, next)
+		}
+
+		b.app.QueueUpdateDraw(func() {
+			b.appended = append(b.appended, appendedNext{url: nextURL, base: renderURL, page: next})
+			if b.expanded {
+				if expanded, err := renderHTMLWith(next.Source, renderURL, renderOptions{expand: true}); err == nil {
+					next = expanded
+				}
+			}
+			row, _ := b.textView.GetScrollOffset()
+			b.setPage(appendPage(b.currentPage(), next, nextURL))
+			b.textView.ScrollTo(row, 0)
+			b.graph.addPage(nextURL, next.Links)
+			b.setStatus("Appended " + nextURL)
+		})
+	}()
+}
+
+// followNextPage navigates to the next page instead of appending it.
+func (b *browser) followNextPage() {
+	if b.nextURL == "" {
+		b.setStatus("No next page")
+		return
+	}
+	b.navigate(b.nextURL)
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// appendedPage renders first and second, shows first in a browser as the
+// UI would, and appends second to what the browser shows.
+func appendedPage(t *testing.T, first, second string) (*Page, *Page) {
+	t.Helper()
+	base := renderPage(t, "html", first)
+	base.Status = "HTTP/1.1 200 OK"
+	base.Header = http.Header{"Content-Type": {"text/html"}}
+	next := renderPage(t, "html", second)
+
+	b := &browser{}
+	b.text, b.links, b.images, b.tables, b.headings = base.Text, base.Links, base.Images, base.Tables, base.Headings
+	b.source, b.nextURL, b.search, b.title = base.Source, base.Next, base.Search, base.Title
+	b.forms, b.responseStatus, b.responseHeader = base.Forms, base.Status, base.Header
+	b.anchors, b.words = base.Anchors, base.Words
+	return appendPage(b.currentPage(), next, "https://example.com/page/2"), next
+}
+
+func TestAppendPageKeepsResponseAndForms(t *testing.T) {
+	merged, _ := appendedPage(t,
+		`<p>First</p><form action=/search><input name=q></form>`,
+		`<p>Second</p>`)
+
+	if len(merged.Forms) != 1 || merged.Forms[0].Action != "https://example.com/search" {
+		t.Errorf("Forms = %+v, want the first page's form", merged.Forms)
+	}
+	if merged.Status != "HTTP/1.1 200 OK" || merged.Header.Get("Content-Type") != "text/html" {
+		t.Errorf("Status, Header = %q, %v; want the first page's response", merged.Status, merged.Header)
+	}
+}
+
+func TestAppendPageKeepsAnchorsOfBothPages(t *testing.T) {
+	merged, next := appendedPage(t,
+		`<h2 id="intro">Intro</h2><p>First</p><p id="shared">First page's</p>`,
+		`<p>Second</p><h2 id="part-two">Part two</h2><p id="shared">Second page's</p>`)
+
+	lines := strings.Split(merged.Text, "\n")
+	for id, want := range map[string]string{"intro": "Intro", "part-two": "Part two", "shared": "First page's"} {
+		line, ok := merged.Anchors[id]
+		if !ok {
+			t.Errorf("anchor %q missing after appending", id)
+			continue
+		}
+		if !strings.Contains(stripTags(lines[line]), want) {
+			t.Errorf("anchor %q points at line %d %q, want the line reading %q", id, line, lines[line], want)
+		}
+	}
+	if merged.Anchors["part-two"] == next.Anchors["part-two"] {
+		t.Error("anchors of the appended page were not shifted")
+	}
+}
+
+func TestAppendPageCountsWordsOfBothPages(t *testing.T) {
+	merged, _ := appendedPage(t, `<p>one two three</p>`, `<p>four five</p>`)
+	if merged.Words != 5 {
+		t.Errorf("Words = %d, want 5", merged.Words)
+	}
+}
+
+func TestAppendPageRenumbersLinks(t *testing.T) {
+	numberLinks := config.NumberLinks
+	config.NumberLinks = true
+	defer func() { config.NumberLinks = numberLinks }()
+
+	merged, _ := appendedPage(t,
+		`<p><a href="/one">One</a></p>`,
+		`<p><a href="/two">Two</a> and <a href="/three">Three</a></p>`)
+
+	for i, link := range merged.Links {
+		if link.Index != i+1 {
+			t.Errorf("link %q has Index %d, want %d", link.Href, link.Index, i+1)
+		}
+	}
+	text := stripTags(merged.Text)
+	for _, want := range []string{"[1] One", "[2] Two", "[3] Three"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("merged text %q does not contain %q", text, want)
+		}
+	}
+	if n := strings.Count(merged.Text, `["link-1"]`); n != 1 {
+		t.Errorf("region link-1 appears %d times, want once", n)
+	}
+	if !strings.Contains(merged.Text, `["link-3"]Three`) {
+		t.Errorf("appended link regions were not renumbered: %q", merged.Text)
+	}
+}
+
+func TestToggleCollapsedKeepsTheView(t *testing.T) {
+	pages := map[string]string{
+		"/one":   `<p>First</p><div class=cookie-banner>We use cookies on page one</div><a rel=next href=/two>Next</a>`,
+		"/two":   `<p>Second</p><div class=cookie-banner>We use cookies on page two</div>`,
+		"/three": `<p>Third</p><div class=cookie-banner>We use cookies on page three</div>`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(pages[r.URL.Path]))
+	}))
+	defer server.Close()
+	b := runningBrowser(t)
+
+	b.app.QueueUpdate(func() { b.navigate(server.URL + "/one") })
+	waitFor(t, b, "page one", func() bool { return b.currentURL == server.URL+"/one" })
+	b.app.QueueUpdate(func() { b.appendNextPage() })
+	waitFor(t, b, "page two to be appended", func() bool { return strings.Contains(b.text, "Second") })
+
+	var text, status string
+	b.app.QueueUpdate(func() {
+		status = b.responseStatus
+		b.toggleCollapsed()
+		text = b.text
+	})
+	for _, want := range []string{"We use cookies on page one", "Second", "We use cookies on page two"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expanded view is missing %q:\n%s", want, text)
+		}
+	}
+	b.app.QueueUpdate(func() {
+		if status == "" || b.responseStatus != status {
+			t.Errorf("responseStatus = %q after expanding, want %q", b.responseStatus, status)
+		}
+	})
+
+	b.app.QueueUpdate(func() { b.navigate(server.URL + "/three") })
+	waitFor(t, b, "page three", func() bool { return b.currentURL == server.URL+"/three" })
+	b.app.QueueUpdate(func() { text = b.text })
+	if strings.Contains(text, "We use cookies on page three") {
+		t.Errorf("expanding carried over to the next page:\n%s", text)
+	}
+}
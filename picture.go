@@ -0,0 +1,90 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// preferredImageWidth is the narrowest source, in pixels, that still gives
+// a detailed ASCII rendering. Wider sources only cost download time.
+const preferredImageWidth = 320
+
+// decodableTypes are the <source type> values the registered decoders
+// handle.
+var decodableTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/jpg":  true,
+	"image/gif":  true,
+}
+
+type pictureCandidate struct {
+	srcsetCandidate
+	decodable bool
+}
+
+// pictureSources picks the sources to try for an <img> inside a <picture>.
+// Media conditions are ignored since a terminal has no viewport to match
+// them against; instead decodable formats come first, then the resolution
+// best suited to ASCII rendering, with the <img> src after the sized
+// sources of its format. It returns the chosen source and the fallbacks in
+// order.
+func pictureSources(picture, img *html.Node, baseURL string) (string, []string) {
+	var candidates []pictureCandidate
+	add := func(srcset, mimeType string) {
+		for _, candidate := range parseSrcset(srcset, baseURL) {
+			decodable := hasDecodableExtension(candidate.URL)
+			if mimeType != "" {
+				decodable = decodableTypes[strings.ToLower(strings.TrimSpace(mimeType))]
+			}
+			candidates = append(candidates, pictureCandidate{candidate, decodable})
+		}
+	}
+	for c := picture.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.Data == "source" {
+			add(getAttr(c, "srcset"), getAttr(c, "type"))
+		}
+	}
+	add(getAttr(img, "srcset"), "")
+	if src := getAttr(img, "src"); src != "" {
+		resolved := resolveURL(baseURL, src)
+		candidates = append(candidates, pictureCandidate{srcsetCandidate{URL: resolved}, hasDecodableExtension(resolved)})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].decodable != candidates[j].decodable {
+			return candidates[i].decodable
+		}
+		return widthRank(candidates[i].Width) < widthRank(candidates[j].Width)
+	})
+
+	var urls []string
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		if !seen[candidate.URL] {
+			seen[candidate.URL] = true
+			urls = append(urls, candidate.URL)
+		}
+	}
+
+	if len(urls) == 0 {
+		return "", nil
+	}
+	return urls[0], urls[1:]
+}
+
+// widthRank orders candidate widths: the narrowest at or above
+// preferredImageWidth first, then narrower ones from widest down, then
+// candidates without a width descriptor.
+func widthRank(width int) int {
+	switch {
+	case width >= preferredImageWidth:
+		return width
+	case width > 0:
+		return math.MaxInt/2 - width
+	}
+	return math.MaxInt
+}
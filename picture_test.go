@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestPictureSourcesPrefersDecodableFormat(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<picture>
+		<source type="image/webp" srcset="/a.webp 320w, /b.webp 640w">
+		<source type="image/jpeg" srcset="/a.jpg 320w, /b.jpg 640w">
+		<img src="/fallback.jpg">
+	</picture>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	picture := findElement(doc, "picture")
+	img := findElement(doc, "img")
+
+	chosen, fallbacks := pictureSources(picture, img, "https://example.com/page")
+	if chosen != "https://example.com/a.jpg" {
+		t.Errorf("chosen = %q, want the narrowest JPEG at the preferred width", chosen)
+	}
+	want := []string{
+		"https://example.com/b.jpg",
+		"https://example.com/fallback.jpg",
+		"https://example.com/a.webp",
+		"https://example.com/b.webp",
+	}
+	if strings.Join(fallbacks, " ") != strings.Join(want, " ") {
+		t.Errorf("fallbacks = %q, want %q", fallbacks, want)
+	}
+}
+
+func TestWidthRankOrder(t *testing.T) {
+	widths := []int{320, 640, 160, 0}
+	for i := 1; i < len(widths); i++ {
+		if widthRank(widths[i-1]) >= widthRank(widths[i]) {
+			t.Errorf("widthRank(%d) should rank before widthRank(%d)", widths[i-1], widths[i])
+		}
+	}
+}
+
+// findElement returns the first element named tag below n.
+func findElement(n *html.Node, tag string) *html.Node {
+	if n.Type == html.ElementNode && n.Data == tag {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElement(c, tag); found != nil {
+			return found
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// progressInterval is how often a progressReader reports, so a fast
+// download does not flood the UI with redraws.
+const progressInterval = 100 * time.Millisecond
+
+// progressFunc receives the bytes read so far and the expected total,
+// which is -1 when the server did not send a Content-Length.
+type progressFunc func(read, total int64)
+
+// progressReader reports how much of r has been read. It calls report at
+// most every progressInterval, and always once r is exhausted.
+type progressReader struct {
+	r      io.Reader
+	total  int64
+	read   int64
+	last   time.Time
+	report progressFunc
+}
+
+func newProgressReader(r io.Reader, total int64, report progressFunc) io.Reader {
+	if report == nil {
+		return r
+	}
+	return &progressReader{r: r, total: total, report: report}
+}
+
+func (p *progressReader) Read(buf []byte) (int, error) {
+	n, err := p.r.Read(buf)
+	p.read += int64(n)
+	if now := time.Now(); err != nil || now.Sub(p.last) >= progressInterval {
+		p.last = now
+		p.report(p.read, p.total)
+	}
+	return n, err
+}
+
+// formatProgress describes a download's progress, as a percentage when
+// the total is known and as the bytes transferred otherwise.
+func formatProgress(read, total int64) string {
+	if total <= 0 {
+		return formatBytes(read)
+	}
+	return fmt.Sprintf("%s / %s (%d%%)", formatBytes(read), formatBytes(total), read*100/total)
+}
+
+// statusProgress returns a progressFunc showing label and the progress in
+// the status bar. It may be called from any goroutine.
+func (b *browser) statusProgress(label string) progressFunc {
+	return func(read, total int64) {
+		b.app.QueueUpdateDraw(func() {
+			b.setStatus(label + " " + formatProgress(read, total))
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// quietHistory is how many recent pages per host are compared to find
+// boilerplate, and quietThreshold how many of them a block must appear on.
+const (
+	quietHistory   = 5
+	quietThreshold = 2
+)
+
+type pageBlocks struct {
+	url    string
+	blocks map[string]bool
+}
+
+// boilerplate remembers the text blocks of recently shown pages per host.
+// It is only used on the UI goroutine.
+var boilerplate = make(map[string][]pageBlocks)
+
+// leafBlocks returns the text of every block element below n that has no
+// block elements inside it, such as a paragraph, list item or heading.
+// These are the units compared across pages.
+func leafBlocks(n *html.Node, blocks map[*html.Node]string) bool {
+	hasBlock := false
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if leafBlocks(c, blocks) {
+			hasBlock = true
+		}
+	}
+	if !isBlockElement(n) {
+		return hasBlock
+	}
+	if !hasBlock {
+		if text := nodeText(n); text != "" {
+			blocks[n] = text
+		}
+	}
+	return true
+}
+
+// suppressBoilerplate records page as seen on its host and renders it
+// again without the blocks that also appear on other recently seen pages
+// of that host, such as shared headers, navigation and footers. It returns
+// the page unchanged when nothing repeats.
+func suppressBoilerplate(pageURL string, page *Page) (*Page, int) {
+	parsed, err := url.Parse(pageURL)
+	if err != nil || parsed.Host == "" || page.Source == "" {
+		return page, 0
+	}
+	host := strings.ToLower(parsed.Host)
+
+	doc, err := html.Parse(strings.NewReader(page.Source))
+	if err != nil {
+		return page, 0
+	}
+	blocks := make(map[*html.Node]string)
+	leafBlocks(doc, blocks)
+
+	seen := make(map[string]bool, len(blocks))
+	for _, text := range blocks {
+		seen[text] = true
+	}
+
+	var previous []pageBlocks
+	for _, p := range boilerplate[host] {
+		if p.url != pageURL {
+			previous = append(previous, p)
+		}
+	}
+	boilerplate[host] = append(previous, pageBlocks{url: pageURL, blocks: seen})
+	if len(boilerplate[host]) > quietHistory {
+		boilerplate[host] = boilerplate[host][1:]
+	}
+
+	skip := make(map[*html.Node]bool)
+	for n, text := range blocks {
+		count := 0
+		for _, p := range previous {
+			if p.blocks[text] {
+				count++
+			}
+		}
+		if count >= quietThreshold {
+			skip[n] = true
+		}
+	}
+	if len(skip) == 0 {
+		return page, 0
+	}
+
+	quiet := renderDocument(doc, page.Source, pageURL, renderOptions{skip: skip, expand: page.Expanded})
+	quiet.Canonical = page.Canonical
+	quiet.Status, quiet.Header = page.Status, page.Header
+	return quiet, len(skip)
+}
+
+// quietPage applies quiet mode to page when it is enabled.
+func (b *browser) quietPage(pageURL string, page *Page) *Page {
+	if !config.QuietMode {
+		return page
+	}
+	quiet, removed := suppressBoilerplate(pageURL, page)
+	if removed > 0 {
+		b.setStatus(fmt.Sprintf("Quiet mode: hid %d blocks repeated on this site", removed))
+	}
+	return quiet
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// maxPositions bounds how many pages remember their scroll position; the
+// least recently read are forgotten first.
+const maxPositions = 500
+
+type scrollPosition struct {
+	Row  int       `json:"row"`
+	Time time.Time `json:"time"`
+}
+
+// scrollPositions maps a page key to where reading stopped, persisted in
+// positions.json next to the config. It is only used on the UI goroutine.
+var scrollPositions = make(map[string]scrollPosition)
+
+func positionsFile() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "positions.json"), nil
+}
+
+func loadPositions() error {
+	filename, err := positionsFile()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading scroll positions: %v", err)
+	}
+	if err := json.Unmarshal(data, &scrollPositions); err != nil {
+		return fmt.Errorf("error parsing scroll positions: %v", err)
+	}
+	return nil
+}
+
+func savePositions() error {
+	if len(scrollPositions) > maxPositions {
+		keys := make([]string, 0, len(scrollPositions))
+		for key := range scrollPositions {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return scrollPositions[keys[i]].Time.After(scrollPositions[keys[j]].Time)
+		})
+		for _, key := range keys[maxPositions:] {
+			delete(scrollPositions, key)
+		}
+	}
+
+	filename, err := positionsFile()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(scrollPositions)
+	if err != nil {
+		return fmt.Errorf("error encoding scroll positions: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return fmt.Errorf("error creating config dir: %v", err)
+	}
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		return fmt.Errorf("error writing scroll positions: %v", err)
+	}
+	return nil
+}
+
+// rememberPosition records how far the current page has been scrolled.
+// Pages read from the top are forgotten rather than stored.
+func (b *browser) rememberPosition() {
+	if b.currentURL == "" {
+		return
+	}
+	key := pageKey(b.currentURL)
+	row, _ := b.textView.GetScrollOffset()
+	if row == 0 {
+		delete(scrollPositions, key)
+		return
+	}
+	scrollPositions[key] = scrollPosition{Row: row, Time: time.Now()}
+}
+
+// restorePosition scrolls a freshly shown page back to where it was last
+// left, or to the top when it has not been read before.
+func (b *browser) restorePosition(pageURL string) {
+	position, ok := scrollPositions[pageKey(pageURL)]
+	if !ok {
+		b.textView.ScrollToBeginning()
+		return
+	}
+	b.textView.ScrollTo(position.Row, 0)
+	b.setStatus(fmt.Sprintf("Resumed at line %d", position.Row+1))
+}
+
+// drawProgress shows how much of the page has been scrolled past in the
+// top right corner of the text view. It runs after each screen update so
+// the page text cannot overwrite it.
+func (b *browser) drawProgress(screen tcell.Screen) {
+	if !config.ReadingProgress {
+		return
+	}
+	if front, _ := b.pages.GetFrontPage(); front != "browser" {
+		return
+	}
+	if front, _ := b.content.GetFrontPage(); front != "text" {
+		return
+	}
+
+	x, y, width, height := b.textView.GetInnerRect()
+	total := b.textView.GetWrappedLineCount()
+	if total <= height {
+		return
+	}
+
+	row, _ := b.textView.GetScrollOffset()
+	percent := 100 * (row + height) / total
+	if percent > 100 {
+		percent = 100
+	}
+	tview.Print(screen, fmt.Sprintf(" %d%% ", percent), x, y, width, tview.AlignRight, tcell.ColorGray)
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// wordsPerMinute is the reading speed reading time estimates assume.
+const wordsPerMinute = 200
+
+// countWords returns the number of words in rendered page text.
+func countWords(text string) int {
+	return len(strings.Fields(stripTags(text)))
+}
+
+// readingStats describes a page of words words and how long it takes to
+// read, rounded up to whole minutes.
+func readingStats(words int) string {
+	if words == 0 {
+		return "0 words"
+	}
+	minutes := (words + wordsPerMinute - 1) / wordsPerMinute
+	unit := "words"
+	if words == 1 {
+		unit = "word"
+	}
+	return fmt.Sprintf("%d %s · %d min read", words, unit, minutes)
+}
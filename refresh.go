@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// maxRefreshRedirects bounds how many meta refreshes are followed in a row
+// so pages refreshing to each other cannot loop forever.
+const maxRefreshRedirects = 5
+
+// findRefresh returns the target and delay of a <meta http-equiv="refresh">
+// in doc. Refreshes of the page itself are ignored.
+func findRefresh(doc *html.Node, currentURL string) (string, time.Duration) {
+	var content string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if content != "" {
+			return
+		}
+		if n.Type == html.ElementNode && n.Data == "meta" && strings.EqualFold(getAttr(n, "http-equiv"), "refresh") {
+			content = getAttr(n, "content")
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	delayText, target, ok := strings.Cut(content, ";")
+	if !ok {
+		delayText, target, ok = strings.Cut(content, ",")
+	}
+	if !ok {
+		return "", 0
+	}
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(delayText), 64)
+	if err != nil || seconds < 0 {
+		return "", 0
+	}
+
+	target = strings.TrimSpace(target)
+	if len(target) > 4 && strings.EqualFold(target[:4], "url=") {
+		target = strings.TrimSpace(target[4:])
+	}
+	target = strings.Trim(target, `'"`)
+	if target == "" {
+		return "", 0
+	}
+
+	resolved := resolveURL(currentURL, target)
+	if resolved == currentURL {
+		return "", 0
+	}
+	return resolved, time.Duration(seconds * float64(time.Second))
+}
+
+// followRefresh acts on a page's meta refresh: immediately when it has no
+// delay, otherwise after the delay if the page is still being shown. The
+// landing page is replaced rather than added to history.
+func (b *browser) followRefresh(pageURL string, page *Page) {
+	if page.Refresh == "" {
+		b.refreshes = 0
+		return
+	}
+	if b.refreshes >= maxRefreshRedirects {
+		b.setStatus(fmt.Sprintf("Stopped after %d refresh redirects", b.refreshes))
+		return
+	}
+
+	target := page.Refresh
+	if message, ok := checkNavigable(target); !ok {
+		b.setStatus(message)
+		return
+	}
+	if !localFileAllowed(pageURL, target) {
+		b.setStatus(errLocalFile.Error())
+		return
+	}
+	if page.RefreshDelay <= 0 {
+		b.refreshes++
+		b.load(target, true, nil)
+		return
+	}
+
+	b.setStatus(fmt.Sprintf("Redirecting to %s in %s", target, page.RefreshDelay))
+	time.AfterFunc(page.RefreshDelay, func() {
+		b.app.QueueUpdateDraw(func() {
+			if b.currentURL == pageURL {
+				b.refreshes++
+				b.load(target, true, nil)
+			}
+		})
+	})
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/rivo/tview"
+	"github.com/rivo/uniseg"
+	"golang.org/x/net/html"
+)
+
+// rtlLanguages are primary language subtags written right-to-left, used
+// when an element declares lang but no dir.
+var rtlLanguages = map[string]bool{
+	"ar": true, "he": true, "fa": true, "ur": true, "yi": true,
+	"ps": true, "dv": true, "ku": true, "sd": true, "ug": true,
+}
+
+// elementDirection returns "rtl" or "ltr" when n sets a text direction via
+// its dir or lang attribute, and an empty string when it inherits one.
+func elementDirection(n *html.Node) string {
+	switch dir := strings.ToLower(getAttr(n, "dir")); dir {
+	case "rtl", "ltr":
+		return dir
+	case "auto":
+		if isRTLText(nodeText(n)) {
+			return "rtl"
+		}
+		return "ltr"
+	}
+
+	if lang := getAttr(n, "lang"); lang != "" {
+		primary, _, _ := strings.Cut(strings.ToLower(lang), "-")
+		if rtlLanguages[primary] {
+			return "rtl"
+		}
+		return "ltr"
+	}
+	return ""
+}
+
+func isRTLRune(r rune) bool {
+	return unicode.In(r, unicode.Arabic, unicode.Hebrew, unicode.Syriac, unicode.Thaana, unicode.Nko)
+}
+
+// isRTLText reports whether the first strongly directional character of
+// text is right-to-left.
+func isRTLText(text string) bool {
+	for _, r := range text {
+		if isRTLRune(r) {
+			return true
+		}
+		if unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return false
+}
+
+// formatRTL lays out a right-to-left paragraph for a terminal that has no
+// bidi support: it wraps the text at width, reorders each line into visual
+// order and right-aligns it.
+func formatRTL(text string, width int) string {
+	var out strings.Builder
+	for _, line := range tview.WordWrap(text, width) {
+		line = visualOrder(strings.TrimSpace(line))
+		if pad := width - uniseg.StringWidth(line); pad > 0 {
+			out.WriteString(strings.Repeat(" ", pad))
+		}
+		out.WriteString(line)
+		out.WriteString("\n")
+	}
+	return out.String()
+}
+
+// visualOrder converts a logically ordered RTL line into display order.
+// Runs of left-to-right text (Latin words, numbers) keep their internal
+// order; everything else is reversed grapheme by grapheme, with mirrored
+// brackets swapped.
+func visualOrder(line string) string {
+	type run struct {
+		ltr       bool
+		graphemes []string
+	}
+
+	var runs []run
+	graphemes := uniseg.NewGraphemes(line)
+	for graphemes.Next() {
+		cluster := graphemes.Str()
+		first := []rune(cluster)[0]
+		ltr := (unicode.IsLetter(first) || unicode.IsDigit(first)) && !isRTLRune(first)
+
+		// Neutral characters between two LTR words stay with them
+		if !ltr && len(runs) > 0 && runs[len(runs)-1].ltr && isNeutral(cluster) {
+			ltr = true
+		}
+
+		if len(runs) == 0 || runs[len(runs)-1].ltr != ltr {
+			runs = append(runs, run{ltr: ltr})
+		}
+		runs[len(runs)-1].graphemes = append(runs[len(runs)-1].graphemes, cluster)
+	}
+
+	// Trailing neutrals borrowed by an LTR run belong to the RTL flow
+	var split []run
+	for _, r := range runs {
+		end := len(r.graphemes)
+		for r.ltr && end > 0 && isNeutral(r.graphemes[end-1]) {
+			end--
+		}
+		split = append(split, run{ltr: r.ltr, graphemes: r.graphemes[:end]})
+		if end < len(r.graphemes) {
+			split = append(split, run{graphemes: r.graphemes[end:]})
+		}
+	}
+	runs = split
+
+	var out strings.Builder
+	for i := len(runs) - 1; i >= 0; i-- {
+		if runs[i].ltr {
+			out.WriteString(strings.Join(runs[i].graphemes, ""))
+			continue
+		}
+		g := runs[i].graphemes
+		for j := len(g) - 1; j >= 0; j-- {
+			out.WriteString(mirrorBracket(g[j]))
+		}
+	}
+	return out.String()
+}
+
+func isNeutral(s string) bool {
+	return s == " " || s == "." || s == "," || s == "-"
+}
+
+func mirrorBracket(s string) string {
+	switch s {
+	case "(":
+		return ")"
+	case ")":
+		return "("
+	case "[":
+		return "]"
+	case "]":
+		return "["
+	case "{":
+		return "}"
+	case "}":
+		return "{"
+	case "<":
+		return ">"
+	case ">":
+		return "<"
+	}
+	return s
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rivo/uniseg"
+)
+
+func TestRTLParagraph(t *testing.T) {
+	defer func(before int) { renderWidth = before }(renderWidth)
+	renderWidth = 30
+
+	doc := `<p>Intro</p><p dir="rtl">שלום עולם (abc 123)</p><div lang="ar">مرحبا</div><p>End</p>`
+	for _, whitespace := range []string{"lines", "html"} {
+		t.Run(whitespace, func(t *testing.T) {
+			lines := strings.Split(renderPage(t, whitespace, doc).Text, "\n")
+			want := map[string]bool{"(abc 123) םלוע םולש": false, "ابحرم": false}
+			for _, line := range lines {
+				text := strings.TrimLeft(line, " ")
+				if _, ok := want[text]; !ok {
+					continue
+				}
+				want[text] = true
+				if width := uniseg.StringWidth(line); width != renderWidth {
+					t.Errorf("RTL line %q is %d columns wide, want it right-aligned to %d", line, width, renderWidth)
+				}
+			}
+			for text, found := range want {
+				if !found {
+					t.Errorf("no line reads %q in visual order: %q", text, lines)
+				}
+			}
+			if lines[0] != "Intro" {
+				t.Errorf("left-to-right text was realigned: %q", lines[0])
+			}
+		})
+	}
+}
+
+func TestRTLParagraphWraps(t *testing.T) {
+	got := formatRTL("אחת שתיים שלוש ארבע", 10)
+	want := " םייתש תחא\n עברא שולש\n"
+	if got != want {
+		t.Errorf("formatRTL = %q, want %q", got, want)
+	}
+}
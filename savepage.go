@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// savePage asks for a path and writes the current page there: the raw
+// HTML when the path ends in .html or .htm, otherwise the rendered text.
+// An existing file is only replaced after confirmation.
+func (b *browser) savePage() {
+	if b.currentURL == "" {
+		b.setStatus("No page loaded")
+		return
+	}
+
+	initial := filepath.Join(exportDir, fmt.Sprintf("page_%s.txt", time.Now().Format("20060102_150405")))
+	b.prompt("Save page to: ", initial, func(path string) {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			return
+		}
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			b.writePage(path)
+			return
+		}
+		b.prompt(path+" exists, overwrite? (y/n) ", "", func(answer string) {
+			if strings.EqualFold(strings.TrimSpace(answer), "y") {
+				b.writePage(path)
+			}
+		})
+	})
+}
+
+// writePage writes the page already in memory to path, reporting the
+// outcome in the status bar.
+func (b *browser) writePage(path string) {
+	content := stripTags(b.text)
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".html" || ext == ".htm" {
+		content = b.source
+	}
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			b.setStatus(fmt.Sprintf("Error creating %s: %v", dir, err))
+			return
+		}
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		b.setStatus(fmt.Sprintf("Error saving page: %v", err))
+		return
+	}
+	b.setStatus(fmt.Sprintf("Saved page to %s (%s)", path, formatBytes(int64(len(content)))))
+}
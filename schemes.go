@@ -0,0 +1,32 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+)
+
+// navigableSchemes are the URL schemes pages can be fetched from.
+var navigableSchemes = map[string]bool{"http": true, "https": true, "file": true}
+
+// checkNavigable reports whether targetURL can be loaded. For links that
+// cannot, it returns a message explaining what the link points to.
+func checkNavigable(targetURL string) (string, bool) {
+	parsed, err := url.Parse(targetURL)
+	if err != nil {
+		// fetchURL reports malformed URLs itself
+		return "", true
+	}
+
+	scheme := strings.ToLower(parsed.Scheme)
+	switch {
+	case scheme == "" || navigableSchemes[scheme]:
+		return "", true
+	case scheme == "mailto":
+		return "Email link: " + strings.SplitN(parsed.Opaque, "?", 2)[0], false
+	case scheme == "tel":
+		return "Phone link: " + parsed.Opaque, false
+	case scheme == "javascript":
+		return "JavaScript links are not supported", false
+	}
+	return "Cannot open " + scheme + ": links", false
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// repeatWindow is how soon a scroll key has to be pressed again (usually by
+// the terminal's key auto-repeat) to count as being held down.
+const repeatWindow = 150 * time.Millisecond
+
+// smoothScroller animates scrolling one line per tick instead of jumping.
+// While a scroll key is held the distance per press grows by the
+// configured acceleration up to a maximum. All fields are only touched on
+// the UI goroutine.
+type smoothScroller struct {
+	b         *browser
+	pending   int
+	velocity  float64
+	lastPress time.Time
+	lastDir   int
+	running   bool
+}
+
+// scroll queues lines of movement in direction (+1 down, -1 up).
+func (s *smoothScroller) scroll(direction, lines int) {
+	now := time.Now()
+	held := direction == s.lastDir && now.Sub(s.lastPress) < repeatWindow
+	s.lastPress, s.lastDir = now, direction
+
+	if held {
+		s.velocity *= config.ScrollAcceleration
+		if maxStep := float64(config.ScrollMaxStep); s.velocity > maxStep {
+			s.velocity = maxStep
+		}
+	} else {
+		s.velocity = 1
+		if direction*s.pending < 0 {
+			// Reversing direction cancels the remaining movement
+			s.pending = 0
+		}
+	}
+
+	distance := int(float64(lines) * s.velocity)
+	if distance < 1 {
+		distance = 1
+	}
+	s.pending += direction * distance
+
+	if !s.running {
+		s.running = true
+		go s.animate()
+	}
+}
+
+func (s *smoothScroller) animate() {
+	interval := time.Duration(config.ScrollInterval) * time.Millisecond
+	if interval <= 0 {
+		interval = 16 * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		done := make(chan bool, 1)
+		s.b.app.QueueUpdateDraw(func() {
+			done <- s.step()
+		})
+		if <-done {
+			return
+		}
+	}
+}
+
+// step moves the view one line toward the pending target and reports
+// whether the animation has finished.
+func (s *smoothScroller) step() bool {
+	if s.pending == 0 {
+		s.running = false
+		return true
+	}
+
+	row, col := s.b.textView.GetScrollOffset()
+	if s.pending > 0 {
+		s.pending--
+		row++
+	} else {
+		s.pending++
+		row--
+	}
+	if row < 0 {
+		row, s.pending = 0, 0
+	}
+	if lines := s.b.textView.GetWrappedLineCount(); row > lines {
+		row, s.pending = lines, 0
+	}
+	s.b.textView.ScrollTo(row, col)
+	return false
+}
+
+// handleScrollKey takes over the scrolling keys in smooth mode. In line
+// mode the event is passed on to the text view's own handling.
+func (b *browser) handleScrollKey(event *tcell.EventKey) *tcell.EventKey {
+	if config.ScrollMode != "smooth" {
+		return event
+	}
+
+	_, _, _, height := b.textView.GetInnerRect()
+	step := config.ScrollStep
+	switch {
+	case event.Key() == tcell.KeyDown || event.Rune() == 'j':
+		b.scroller.scroll(1, step)
+	case event.Key() == tcell.KeyUp || event.Rune() == 'k':
+		b.scroller.scroll(-1, step)
+	case event.Key() == tcell.KeyPgDn || event.Key() == tcell.KeyCtrlF || event.Rune() == ' ':
+		b.scroller.scroll(1, height)
+	case event.Key() == tcell.KeyPgUp || event.Key() == tcell.KeyCtrlB:
+		b.scroller.scroll(-1, height)
+	default:
+		return event
+	}
+	return nil
+}
+
+// scrollTo jumps to row, or to the end of the page when row is -1,
+// dropping any smooth scrolling still in progress.
+func (b *browser) scrollTo(row int) {
+	b.scroller.pending = 0
+	if row == -1 {
+		b.textView.ScrollToEnd()
+		return
+	}
+	_, col := b.textView.GetScrollOffset()
+	b.textView.ScrollTo(max(row, 0), col)
+}
+
+// scrollHalfPage moves half a screen down (+1) or up (-1).
+func (b *browser) scrollHalfPage(direction int) {
+	_, _, _, height := b.textView.GetInnerRect()
+	if config.ScrollMode == "smooth" {
+		b.scroller.scroll(direction, max(height/2, 1))
+		return
+	}
+	row, _ := b.textView.GetScrollOffset()
+	b.scrollTo(row + direction*max(height/2, 1))
+}
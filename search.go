@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// SearchForm is a site's primary search form, reduced to what is needed to
+// submit a query through it.
+type SearchForm struct {
+	Action string
+	Method string
+	Field  string
+	Hidden url.Values
+}
+
+// searchFieldNames are input names commonly used for a search query.
+var searchFieldNames = map[string]bool{
+	"q": true, "query": true, "search": true, "s": true, "k": true,
+	"keywords": true, "term": true, "searchterm": true, "search_query": true,
+}
+
+// findSearchForm returns the first form in doc that looks like a site
+// search: one with an input of type search, or a single text input whose
+// name, id or placeholder suggests searching. Forms with password fields
+// are never considered.
+func findSearchForm(doc *html.Node, currentURL string) *SearchForm {
+	var found *SearchForm
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if found != nil {
+			return
+		}
+		if n.Type == html.ElementNode && n.Data == "form" {
+			found = searchFormFrom(n, currentURL)
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return found
+}
+
+func searchFormFrom(form *html.Node, currentURL string) *SearchForm {
+	var field, candidate string
+	var textInputs int
+	hidden := url.Values{}
+	formIsSearch := strings.EqualFold(getAttr(form, "role"), "search")
+
+	var walk func(*html.Node) bool
+	walk = func(n *html.Node) bool {
+		if n.Type == html.ElementNode && n.Data == "input" {
+			name := getAttr(n, "name")
+			switch strings.ToLower(getAttr(n, "type")) {
+			case "password":
+				return false
+			case "hidden":
+				if name != "" {
+					hidden.Add(name, getAttr(n, "value"))
+				}
+			case "search":
+				if name != "" && field == "" {
+					field = name
+				}
+			case "", "text":
+				textInputs++
+				hint := strings.ToLower(getAttr(n, "id") + " " + getAttr(n, "placeholder") + " " + getAttr(n, "aria-label"))
+				if name != "" && candidate == "" && (searchFieldNames[strings.ToLower(name)] || strings.Contains(hint, "search") || formIsSearch) {
+					candidate = name
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if !walk(c) {
+				return false
+			}
+		}
+		return true
+	}
+	if !walk(form) {
+		return nil
+	}
+
+	if field == "" && textInputs == 1 {
+		field = candidate
+	}
+	if field == "" {
+		return nil
+	}
+
+	method := strings.ToUpper(getAttr(form, "method"))
+	if method != "POST" {
+		method = "GET"
+	}
+	action := getAttr(form, "action")
+	if action == "" {
+		action = currentURL
+	}
+
+	return &SearchForm{
+		Action: resolveURL(currentURL, action),
+		Method: method,
+		Field:  field,
+		Hidden: hidden,
+	}
+}
+
+// values returns the form fields to submit for query.
+func (f *SearchForm) values(query string) url.Values {
+	values := url.Values{}
+	for key, vals := range f.Hidden {
+		values[key] = append([]string(nil), vals...)
+	}
+	values.Set(f.Field, query)
+	return values
+}
+
+// searchSite prompts for a query and submits it through the page's search
+// form, rendering the results like any other page.
+func (b *browser) searchSite() {
+	form := b.search
+	if form == nil {
+		b.setStatus("No search form on this page")
+		return
+	}
+
+	b.prompt("Search: ", "", func(query string) {
+		if strings.TrimSpace(query) == "" {
+			return
+		}
+		values := form.values(query)
+
+		if form.Method == "GET" {
+			target, err := url.Parse(form.Action)
+			if err != nil {
+				b.setStatus(fmt.Sprintf("Error parsing search URL: %v", err))
+				return
+			}
+			target.RawQuery = values.Encode()
+			b.navigate(target.String())
+			return
+		}
+
+		b.postForm(form.Action, values)
+	})
+}
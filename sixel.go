@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// sixelEnabled shows images as sixel graphics instead of ASCII art. It is
+// only set when requested with -sixel and the terminal supports it.
+var sixelEnabled bool
+
+// Images are scaled down to fit this many pixels before encoding.
+const (
+	sixelMaxWidth  = 800
+	sixelMaxHeight = 600
+)
+
+// terminalSupportsSixel asks the terminal for its primary device
+// attributes, which include 4 when sixel graphics are available. It must
+// run before the screen is initialized.
+func terminalSupportsSixel() bool {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return false
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return false
+	}
+	defer term.Restore(fd, state)
+
+	if _, err := os.Stdout.WriteString("\x1b[c"); err != nil {
+		return false
+	}
+
+	reply := make(chan string, 1)
+	go func() {
+		// The reply looks like ESC [ ? 62 ; 4 ; 22 c
+		response, _ := bufio.NewReader(os.Stdin).ReadString('c')
+		reply <- response
+	}()
+
+	select {
+	case response := <-reply:
+		response = strings.TrimSuffix(strings.TrimPrefix(response, "\x1b[?"), "c")
+		for _, attr := range strings.Split(response, ";") {
+			if attr == "4" {
+				return true
+			}
+		}
+	case <-time.After(time.Second):
+	}
+	return false
+}
+
+// encodeSixel returns img as a sixel escape sequence, scaled to fit
+// sixelMaxWidth by sixelMaxHeight and dithered to a 256 color palette.
+func encodeSixel(img image.Image) string {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > sixelMaxWidth {
+		height = height * sixelMaxWidth / width
+		width = sixelMaxWidth
+	}
+	if height > sixelMaxHeight {
+		width = width * sixelMaxHeight / height
+		height = sixelMaxHeight
+	}
+	width, height = max(width, 1), max(height, 1)
+
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			scaled.Set(x, y, img.At(bounds.Min.X+x*bounds.Dx()/width, bounds.Min.Y+y*bounds.Dy()/height))
+		}
+	}
+	paletted := image.NewPaletted(scaled.Bounds(), palette.Plan9)
+	draw.FloydSteinberg.Draw(paletted, paletted.Bounds(), scaled, image.Point{})
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "\x1bPq\"1;1;%d;%d", width, height)
+	for i, c := range paletted.Palette {
+		r, g, b, _ := c.RGBA()
+		fmt.Fprintf(&out, "#%d;2;%d;%d;%d", i, r*100/0xffff, g*100/0xffff, b*100/0xffff)
+	}
+
+	// Each band covers six rows; every color used in it is drawn as one
+	// pass across the band, returning to its start with $
+	for top := 0; top < height; top += 6 {
+		used := make(map[uint8]bool)
+		for y := top; y < min(top+6, height); y++ {
+			for x := 0; x < width; x++ {
+				used[paletted.ColorIndexAt(x, y)] = true
+			}
+		}
+		for i := range paletted.Palette {
+			index := uint8(i)
+			if !used[index] {
+				continue
+			}
+			fmt.Fprintf(&out, "#%d", index)
+			var run []byte
+			for x := 0; x < width; x++ {
+				var bits byte
+				for dy := 0; dy < 6 && top+dy < height; dy++ {
+					if paletted.ColorIndexAt(x, top+dy) == index {
+						bits |= 1 << dy
+					}
+				}
+				run = append(run, '?'+bits)
+			}
+			out.WriteString(compressSixels(run))
+			out.WriteString("$")
+		}
+		out.WriteString("-")
+	}
+	out.WriteString("\x1b\\")
+	return out.String()
+}
+
+// compressSixels run-length encodes repeated sixel characters.
+func compressSixels(run []byte) string {
+	var out strings.Builder
+	for i := 0; i < len(run); {
+		j := i
+		for j < len(run) && run[j] == run[i] {
+			j++
+		}
+		if count := j - i; count > 3 {
+			fmt.Fprintf(&out, "!%d%c", count, run[i])
+		} else {
+			out.Write(run[i:j])
+		}
+		i = j
+	}
+	return out.String()
+}
+
+// showSixel suspends the interface, draws the encoded image and waits for
+// Enter before returning to the page.
+func (b *browser) showSixel(img ImageInfo, sixel string) {
+	b.app.Suspend(func() {
+		fmt.Print("\x1b[2J\x1b[H" + sixel)
+		fmt.Printf("\n%s\nPress Enter to return", img.Src)
+		bufio.NewReader(os.Stdin).ReadString('\n')
+	})
+	b.setStatus(describeImage(b.imageIndex, len(b.images), img))
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// expandSnippet fills a snippet template from the current page URL. The
+// supported placeholders are {url}, {url:escaped}, {host}, {path} and
+// {query}.
+func expandSnippet(template, pageURL string) (string, error) {
+	parsedURL, err := url.Parse(pageURL)
+	if err != nil {
+		return "", fmt.Errorf("error parsing URL: %v", err)
+	}
+
+	replacer := strings.NewReplacer(
+		"{url}", pageURL,
+		"{url:escaped}", url.QueryEscape(pageURL),
+		"{host}", parsedURL.Host,
+		"{path}", parsedURL.Path,
+		"{query}", parsedURL.RawQuery,
+	)
+	return replacer.Replace(template), nil
+}
+
+func snippetNames() []string {
+	names := make([]string, 0, len(config.Snippets))
+	for name := range config.Snippets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (b *browser) snippetCommand(args string) {
+	if args == "" {
+		b.setStatus("Snippets: " + strings.Join(snippetNames(), ", "))
+		return
+	}
+
+	template, ok := config.Snippets[args]
+	if !ok {
+		b.setStatus(fmt.Sprintf("Unknown snippet %q", args))
+		return
+	}
+	if b.currentURL == "" {
+		b.setStatus("No page loaded")
+		return
+	}
+
+	target, err := expandSnippet(template, displayURL(b.currentURL))
+	if err != nil {
+		b.setStatus(err.Error())
+		return
+	}
+	b.navigate(target)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// decodableAccept is sent with image requests so servers that negotiate
+// formats return something image.Decode understands instead of WebP/AVIF.
+const decodableAccept = "image/png,image/jpeg,image/gif;q=0.9,*/*;q=0.1"
+
+var errUnsupportedImage = errors.New("[unsupported image format]")
+
+// srcsetCandidate is one entry of a srcset attribute. Width is the w
+// descriptor and Density the x descriptor; either may be zero.
+type srcsetCandidate struct {
+	URL     string
+	Width   int
+	Density float64
+}
+
+// parseSrcset splits a srcset attribute into candidates with their URLs
+// resolved against baseURL. As in the HTML standard, a URL runs up to
+// whitespace, so it may contain commas, and its descriptors run up to the
+// next comma outside parentheses. Malformed descriptors are ignored.
+func parseSrcset(srcset, baseURL string) []srcsetCandidate {
+	var candidates []srcsetCandidate
+	isSpace := func(c byte) bool { return strings.IndexByte(" \t\n\r\f", c) >= 0 }
+	for i := 0; i < len(srcset); {
+		for i < len(srcset) && (isSpace(srcset[i]) || srcset[i] == ',') {
+			i++
+		}
+		start := i
+		for i < len(srcset) && !isSpace(srcset[i]) {
+			i++
+		}
+		rawURL := srcset[start:i]
+		if rawURL == "" {
+			break
+		}
+
+		var descriptors string
+		if trimmed := strings.TrimRight(rawURL, ","); trimmed != rawURL {
+			// A comma ending the URL also ends the candidate
+			rawURL = trimmed
+		} else {
+			start, depth := i, 0
+			for ; i < len(srcset) && (srcset[i] != ',' || depth > 0); i++ {
+				switch srcset[i] {
+				case '(':
+					depth++
+				case ')':
+					depth = max(depth-1, 0)
+				}
+			}
+			descriptors = srcset[start:i]
+		}
+
+		candidate := srcsetCandidate{URL: resolveURL(baseURL, rawURL)}
+		for _, descriptor := range strings.Fields(descriptors) {
+			switch {
+			case strings.HasSuffix(descriptor, "w"):
+				candidate.Width, _ = strconv.Atoi(strings.TrimSuffix(descriptor, "w"))
+			case strings.HasSuffix(descriptor, "x"):
+				candidate.Density, _ = strconv.ParseFloat(strings.TrimSuffix(descriptor, "x"), 64)
+			}
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
+// hasDecodableExtension reports whether imageURL looks like a format the
+// registered decoders handle.
+func hasDecodableExtension(imageURL string) bool {
+	switch strings.ToLower(path.Ext(strings.SplitN(imageURL, "?", 2)[0])) {
+	case ".png", ".jpg", ".jpeg", ".gif":
+		return true
+	}
+	return false
+}
+
+// candidateURLs orders srcset candidates for fallback, trying URLs with a
+// decodable extension first and otherwise keeping the document order.
+func candidateURLs(candidates []srcsetCandidate) []string {
+	urls := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		urls = append(urls, candidate.URL)
+	}
+	sort.SliceStable(urls, func(i, j int) bool {
+		return hasDecodableExtension(urls[i]) && !hasDecodableExtension(urls[j])
+	})
+	return urls
+}
+
+// fetchDecodableImage downloads img, falling back to its alternate
+// candidates until one decodes. It returns errUnsupportedImage when every
+// source downloaded but none could be decoded. pageURL is the page img is
+// on and progress may be nil.
+func fetchDecodableImage(img ImageInfo, pageURL string, progress progressFunc) (string, error) {
+	var lastErr error
+	decodeFailed := false
+	for _, src := range append([]string{img.Src}, img.Candidates...) {
+		filename, err := downloadImageProgress(src, pageURL, progress)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if _, err := decodeImageCached(filename); err != nil {
+			lastErr = err
+			decodeFailed = true
+			continue
+		}
+		return filename, nil
+	}
+
+	if decodeFailed {
+		return "", errUnsupportedImage
+	}
+	return "", lastErr
+}
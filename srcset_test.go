@@ -0,0 +1,47 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSrcset(t *testing.T) {
+	tests := []struct {
+		srcset string
+		want   []srcsetCandidate
+	}{
+		{"small.png 480w, large.png 1080w", []srcsetCandidate{
+			{URL: "https://example.com/img/small.png", Width: 480},
+			{URL: "https://example.com/img/large.png", Width: 1080},
+		}},
+		{"a.png, b.png 2x", []srcsetCandidate{
+			{URL: "https://example.com/img/a.png"},
+			{URL: "https://example.com/img/b.png", Density: 2},
+		}},
+		// Commas inside a URL belong to it
+		{"/resize/w_480,h_320/photo.jpg 480w, /resize/w_960,h_640/photo.jpg 960w", []srcsetCandidate{
+			{URL: "https://example.com/resize/w_480,h_320/photo.jpg", Width: 480},
+			{URL: "https://example.com/resize/w_960,h_640/photo.jpg", Width: 960},
+		}},
+		{"data:image/png;base64,iVBORw0KGgo= 1x", []srcsetCandidate{
+			{URL: "data:image/png;base64,iVBORw0KGgo=", Density: 1},
+		}},
+		{"  ,one.png,,two.png 2x,  ", []srcsetCandidate{
+			{URL: "https://example.com/img/one.png,,two.png", Density: 2},
+		}},
+		{"one.png,, two.png", []srcsetCandidate{
+			{URL: "https://example.com/img/one.png"},
+			{URL: "https://example.com/img/two.png"},
+		}},
+		{"x.png 100w (future, descriptor), y.png 200w", []srcsetCandidate{
+			{URL: "https://example.com/img/x.png", Width: 100},
+			{URL: "https://example.com/img/y.png", Width: 200},
+		}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := parseSrcset(tt.srcset, "https://example.com/img/page.html"); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseSrcset(%q) = %+v, want %+v", tt.srcset, got, tt.want)
+		}
+	}
+}
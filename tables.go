@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"golang.org/x/net/html"
+)
+
+// TableInfo holds the cell text of a <table> and the rendered line where
+// it starts, so the table can be shown in its own scrollable view.
+type TableInfo struct {
+	Rows   [][]string
+	Header bool
+	Line   int
+}
+
+// tableRows collects the text of every cell, row by row, without
+// descending into nested tables. header reports whether the first row is
+// made up of <th> cells.
+func tableRows(table *html.Node) (rows [][]string, header bool) {
+	cells, header := tableCells(table)
+	for _, row := range cells {
+		texts := make([]string, len(row))
+		for i, cell := range row {
+			texts[i] = nodeText(cell)
+		}
+		rows = append(rows, texts)
+	}
+	return rows, header
+}
+
+// tableCells returns the <td> and <th> elements of table row by row,
+// without descending into nested tables.
+func tableCells(table *html.Node) (rows [][]*html.Node, header bool) {
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			switch c.Data {
+			case "table":
+				continue
+			case "tr":
+				var row []*html.Node
+				allHeaders := true
+				for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type == html.ElementNode && (cell.Data == "td" || cell.Data == "th") {
+						row = append(row, cell)
+						allHeaders = allHeaders && cell.Data == "th"
+					}
+				}
+				if len(rows) == 0 {
+					header = allHeaders && len(row) > 0
+				}
+				rows = append(rows, row)
+			default:
+				walk(c)
+			}
+		}
+	}
+	walk(table)
+	return rows, header
+}
+
+// tableGridLine returns the line of the grid formatTable renders for row,
+// counting the rule below a header row.
+func tableGridLine(row int, header bool) int {
+	if header && row > 0 {
+		return row + 1
+	}
+	return row
+}
+
+// formatTable lays out rows, whose cells may contain style tags, as a grid
+// of aligned columns. Short rows are padded with empty cells. With header
+// the first row is bold and ruled off from the rest.
+func formatTable(rows [][]string, header bool) string {
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], tview.TaggedStringWidth(cell))
+		}
+	}
+
+	var out strings.Builder
+	for r, row := range rows {
+		for i, width := range widths {
+			var cell string
+			if i < len(row) {
+				cell = row[i]
+			}
+			if i > 0 {
+				out.WriteString(" │ ")
+			}
+			if r == 0 && header {
+				cell = "[::b]" + cell + "[::-]"
+			}
+			out.WriteString(cell)
+			if i < len(widths)-1 {
+				out.WriteString(strings.Repeat(" ", width-tview.TaggedStringWidth(cell)))
+			}
+		}
+		out.WriteString("\n")
+
+		if r == 0 && header {
+			for i, width := range widths {
+				if i > 0 {
+					out.WriteString("─┼─")
+				}
+				out.WriteString(strings.Repeat("─", width))
+			}
+			out.WriteString("\n")
+		}
+	}
+	return out.String()
+}
+
+// nodeText returns the visible text below n with whitespace collapsed.
+func nodeText(n *html.Node) string {
+	var parts []string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && (n.Data == "script" || n.Data == "style") {
+			return
+		}
+		if n.Type == html.TextNode {
+			parts = append(parts, strings.Fields(n.Data)...)
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(parts, " ")
+}
+
+// viewTable opens the first table at or below the top of the viewport in
+// a tview.Table, which scrolls sideways independently of the page.
+func (b *browser) viewTable() {
+	if len(b.tables) == 0 {
+		b.setStatus("No tables on this page")
+		return
+	}
+
+	row, _ := b.textView.GetScrollOffset()
+	top := b.logicalLine(row)
+	index := len(b.tables) - 1
+	for i, table := range b.tables {
+		if top >= 0 && table.Line >= top {
+			index = i
+			break
+		}
+	}
+
+	b.showTable(index)
+}
+
+func (b *browser) showTable(index int) {
+	info := b.tables[index]
+
+	table := tview.NewTable().
+		SetBorders(true).
+		SetSelectable(true, true)
+	if len(info.Rows) > 0 && info.Header {
+		table.SetFixed(1, 0)
+	}
+	for r, row := range info.Rows {
+		for c, text := range row {
+			cell := tview.NewTableCell(tview.Escape(text))
+			if r == 0 && info.Header {
+				cell.SetAttributes(tcell.AttrBold).SetSelectable(false)
+			}
+			table.SetCell(r, c, cell)
+		}
+	}
+
+	table.SetBorder(true).SetTitle(fmt.Sprintf(" table %d/%d ", index+1, len(b.tables)))
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEscape || event.Rune() == 'q':
+			b.closeOverlay("table")
+			return nil
+		case event.Rune() == 'n' && index+1 < len(b.tables):
+			b.closeOverlay("table")
+			b.showTable(index + 1)
+			return nil
+		case event.Rune() == 'p' && index > 0:
+			b.closeOverlay("table")
+			b.showTable(index - 1)
+			return nil
+		}
+		return event
+	})
+
+	b.showOverlay("table", table)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// tab is a page left open in the background. Like history it only keeps
+// URLs: switching to a tab loads its page again, normally straight from
+// the session cache.
+type tab struct {
+	url     string
+	back    []string
+	forward []string
+}
+
+// handleTabKey opens, closes and switches tabs: Ctrl-T opens the selected
+// link, or the current page, in a new tab, Ctrl-W closes the tab,
+// Ctrl-PgDn and Ctrl-PgUp cycle through the tabs and Alt+1..8 jump to a tab
+// by number, with Alt+9 going to the last one. It returns nil when the key
+// was consumed.
+func (b *browser) handleTabKey(event *tcell.EventKey) *tcell.EventKey {
+	switch {
+	case event.Key() == tcell.KeyCtrlT:
+		target := b.currentURL
+		if link, ok := b.currentLink(); ok {
+			target = link.Href
+		}
+		b.openTab(target)
+	case event.Key() == tcell.KeyCtrlW:
+		b.closeTab()
+	case event.Key() == tcell.KeyPgDn && event.Modifiers()&tcell.ModCtrl != 0:
+		b.switchTab(b.activeTab + 1)
+	case event.Key() == tcell.KeyPgUp && event.Modifiers()&tcell.ModCtrl != 0:
+		b.switchTab(b.activeTab - 1)
+	case event.Key() == tcell.KeyRune && event.Modifiers()&tcell.ModAlt != 0 && event.Rune() >= '1' && event.Rune() <= '9':
+		if event.Rune() == '9' {
+			b.switchTab(len(b.tabs) - 1)
+		} else {
+			b.jumpToTab(int(event.Rune() - '1'))
+		}
+	default:
+		return event
+	}
+	return nil
+}
+
+// saveTab records the current page and its history in the active tab.
+func (b *browser) saveTab() {
+	b.tabs[b.activeTab] = tab{url: b.currentURL, back: b.back, forward: b.forward}
+}
+
+// openTab loads targetURL in a new tab after the others.
+func (b *browser) openTab(targetURL string) {
+	if targetURL == "" {
+		b.setStatus("Nothing to open in a new tab")
+		return
+	}
+	if !localFileAllowed(b.currentURL, targetURL) {
+		b.setStatus(errLocalFile.Error())
+		return
+	}
+	if message, ok := checkNavigable(targetURL); !ok {
+		b.setStatus(message)
+		return
+	}
+	b.refreshes = 0
+	b.load(targetURL, true, func() {
+		b.saveTab()
+		b.tabs = append(b.tabs, tab{})
+		b.activeTab = len(b.tabs) - 1
+		b.back, b.forward = nil, nil
+	})
+}
+
+// jumpToTab switches to tab index, counted from 0, if there is one.
+func (b *browser) jumpToTab(index int) {
+	if index >= len(b.tabs) {
+		b.setStatus(fmt.Sprintf("No tab %d (%d open)", index+1, len(b.tabs)))
+		return
+	}
+	b.switchTab(index)
+}
+
+// switchTab makes tab index the active one, wrapping around at either end
+// so it can be used to cycle through the tabs.
+func (b *browser) switchTab(index int) {
+	if len(b.tabs) < 2 {
+		b.setStatus("No other tabs open")
+		return
+	}
+	index = (index + len(b.tabs)) % len(b.tabs)
+	if index == b.activeTab {
+		return
+	}
+	target := b.tabs[index]
+	b.refreshes = 0
+	b.load(target.url, true, func() {
+		b.saveTab()
+		b.activeTab = index
+		b.back, b.forward = target.back, target.forward
+	})
+}
+
+// closeTab closes the active tab and shows the one after it, or the one
+// before it when it was the last.
+func (b *browser) closeTab() {
+	if len(b.tabs) < 2 {
+		b.setStatus("Cannot close the last tab")
+		return
+	}
+	closing := b.activeTab
+	next := closing + 1
+	if next == len(b.tabs) {
+		next = closing - 1
+	}
+	target := b.tabs[next]
+	b.refreshes = 0
+	b.load(target.url, true, func() {
+		b.tabs = append(b.tabs[:closing], b.tabs[closing+1:]...)
+		b.activeTab = min(next, closing)
+		b.back, b.forward = target.back, target.forward
+	})
+}
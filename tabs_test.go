@@ -0,0 +1,108 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// runningBrowser starts a browser on a simulated screen and stops it when
+// the test ends.
+func runningBrowser(t *testing.T) *browser {
+	t.Helper()
+	// showPage saves to the history file, so keep it out of the user's
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatal(err)
+	}
+	b := newBrowser()
+	b.app.SetScreen(screen).SetRoot(b.pages, true)
+	done := make(chan error, 1)
+	go func() { done <- b.app.Run() }()
+	t.Cleanup(func() {
+		b.app.Stop()
+		<-done
+	})
+	return b
+}
+
+// waitFor runs check on the UI goroutine until it returns true.
+func waitFor(t *testing.T, b *browser, what string, check func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var ok bool
+		b.app.QueueUpdate(func() { ok = check() })
+		if ok {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// localPages writes a page for each name and returns their file: URLs.
+func localPages(t *testing.T, names ...string) []string {
+	t.Helper()
+	dir := t.TempDir()
+	var urls []string
+	for _, name := range names {
+		path := filepath.Join(dir, name+".html")
+		if err := os.WriteFile(path, []byte("<title>"+name+"</title><p>"+name+"</p>"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		target, err := fileURL(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		urls = append(urls, target)
+	}
+	return urls
+}
+
+func TestTabShortcuts(t *testing.T) {
+	b := runningBrowser(t)
+	pages := localPages(t, "one", "two", "three")
+	on := func(url string, tab int) func() bool {
+		return func() bool { return b.currentURL == url && b.activeTab == tab }
+	}
+	press := func(event *tcell.EventKey) {
+		b.app.QueueUpdate(func() { b.handleKey(event) })
+	}
+	alt := func(digit rune) *tcell.EventKey { return tcell.NewEventKey(tcell.KeyRune, digit, tcell.ModAlt) }
+
+	b.app.QueueUpdate(func() { b.openURL(pages[0]) })
+	waitFor(t, b, "the first page", on(pages[0], 0))
+	b.app.QueueUpdate(func() { b.openTab(pages[1]) })
+	waitFor(t, b, "a second tab", on(pages[1], 1))
+	b.app.QueueUpdate(func() { b.openTab(pages[2]) })
+	waitFor(t, b, "a third tab", on(pages[2], 2))
+
+	press(alt('1'))
+	waitFor(t, b, "Alt+1 to show the first tab", on(pages[0], 0))
+	press(alt('9'))
+	waitFor(t, b, "Alt+9 to show the last tab", on(pages[2], 2))
+	press(alt('2'))
+	waitFor(t, b, "Alt+2 to show the second tab", on(pages[1], 1))
+	press(tcell.NewEventKey(tcell.KeyPgDn, 0, tcell.ModCtrl))
+	waitFor(t, b, "Ctrl-PgDn to cycle to the third tab", on(pages[2], 2))
+	press(tcell.NewEventKey(tcell.KeyPgDn, 0, tcell.ModCtrl))
+	waitFor(t, b, "Ctrl-PgDn to wrap to the first tab", on(pages[0], 0))
+
+	press(alt('5'))
+	waitFor(t, b, "a missing tab to be reported", func() bool {
+		return b.statusView.GetText(true) == "No tab 5 (3 open)" && b.currentURL == pages[0]
+	})
+
+	press(tcell.NewEventKey(tcell.KeyCtrlW, 0, tcell.ModCtrl))
+	waitFor(t, b, "Ctrl-W to close the first tab", on(pages[1], 0))
+	waitFor(t, b, "two tabs left", func() bool { return len(b.tabs) == 2 && b.tabs[1].url == pages[2] })
+}
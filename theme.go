@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+type colorLevel int
+
+const (
+	colorAuto colorLevel = iota
+	colorNone
+	color8
+	color256
+	colorTrue
+)
+
+// theme holds the tview style tags used for rendered content and UI
+// messages. Every tag is closed with "[-::-]" so themes can mix colors and
+// attributes freely.
+type theme struct {
+	address  string
+	accent   string
+	muted    string
+	errorTag string
+	added    string
+	removed  string
+
+	// match marks search results. It sets a background, so it is closed
+	// with "[-:-:-]" instead.
+	match string
+}
+
+var themes = map[colorLevel]theme{
+	colorNone: {
+		address:  "[::i]",
+		accent:   "[::b]",
+		muted:    "[::d]",
+		errorTag: "[::r]",
+		added:    "[::b]",
+		removed:  "[::d]",
+		match:    "[::u]",
+	},
+	color8: {
+		address:  "[teal::i]",
+		accent:   "[olive::b]",
+		muted:    "[::d]",
+		errorTag: "[maroon::b]",
+		added:    "[green]",
+		removed:  "[maroon]",
+		match:    "[black:olive]",
+	},
+	color256: {
+		address:  "[teal::i]",
+		accent:   "[yellow]",
+		muted:    "[gray]",
+		errorTag: "[red]",
+		added:    "[green]",
+		removed:  "[red]",
+		match:    "[black:yellow]",
+	},
+	colorTrue: {
+		address:  "[#5fafaf::i]",
+		accent:   "[#ffd75f]",
+		muted:    "[#8a8a8a]",
+		errorTag: "[#ff5f5f]",
+		added:    "[#87d787]",
+		removed:  "[#ff5f5f]",
+		match:    "[#000000:#ffd75f]",
+	},
+}
+
+var activeTheme = themes[color256]
+
+func parseColorLevel(value string) (colorLevel, error) {
+	switch value {
+	case "", "auto":
+		return colorAuto, nil
+	case "none", "mono", "0", "2":
+		return colorNone, nil
+	case "8", "16":
+		return color8, nil
+	case "256":
+		return color256, nil
+	case "true", "truecolor", "24bit":
+		return colorTrue, nil
+	}
+	return colorAuto, fmt.Errorf("unknown color level %q (use auto, none, 8, 256 or truecolor)", value)
+}
+
+// detectColorLevel maps the number of colors tcell reports for the
+// terminal onto one of the theme levels.
+func detectColorLevel(screen tcell.Screen) colorLevel {
+	colors := screen.Colors()
+	switch {
+	case colors >= 1<<24:
+		return colorTrue
+	case colors >= 256:
+		return color256
+	case colors >= 8:
+		return color8
+	}
+	return colorNone
+}
+
+func setColorLevel(level colorLevel) {
+	activeTheme = themes[level]
+}
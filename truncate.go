@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// truncateText returns the first limit lines of text followed by a marker
+// when text is longer than that, so huge pages display quickly. A limit of
+// zero or less disables truncation.
+func truncateText(text string, limit int) (string, bool) {
+	if limit <= 0 {
+		return text, false
+	}
+
+	end := 0
+	for i := 0; i < limit; i++ {
+		next := strings.IndexByte(text[end:], '\n')
+		if next < 0 {
+			return text, false
+		}
+		end += next + 1
+	}
+	if end == len(text) {
+		return text, false
+	}
+
+	remaining := strings.Count(text[end:], "\n")
+	if !strings.HasSuffix(text, "\n") {
+		remaining++
+	}
+	marker := fmt.Sprintf("\n%s… %d more lines (press L to load the rest)[-::-]\n", activeTheme.muted, remaining)
+	return text[:end] + marker, true
+}
+
+// loadRest replaces a truncated page with its full text, keeping the
+// scroll position.
+func (b *browser) loadRest() {
+	if !b.truncated {
+		b.setStatus("Page is fully loaded")
+		return
+	}
+
+	row, col := b.textView.GetScrollOffset()
+	b.textView.SetText(b.text)
+	b.textView.ScrollTo(row, col)
+	b.columns.setText(b.text)
+	b.shown = b.text
+	b.truncated = false
+	b.findCount = 0
+	b.setStatus(fmt.Sprintf("Loaded all %d lines", strings.Count(b.text, "\n")))
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// maxHistoryEntries bounds the history file; the oldest visits are dropped
+// first.
+const maxHistoryEntries = 1000
+
+// HistoryEntry is one visit in the persistent browsing history.
+type HistoryEntry struct {
+	URL   string    `json:"url"`
+	Title string    `json:"title"`
+	Time  time.Time `json:"time"`
+}
+
+// history holds every recorded visit, oldest first. Pages are recorded from
+// the fetch goroutines, so access goes through historyMu.
+var (
+	history   []HistoryEntry
+	historyMu sync.Mutex
+)
+
+// historyFile returns where the history is kept: history.json under
+// $XDG_DATA_HOME/just-browsing, falling back to ~/.local/share.
+func historyFile() (string, error) {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("error finding home dir: %v", err)
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dir, "just-browsing", "history.json"), nil
+}
+
+// loadHistory reads the saved history. A missing file is not an error. A
+// corrupt one is moved aside to history.json.bak so it is not overwritten,
+// and browsing starts with an empty history.
+func loadHistory() error {
+	filename, err := historyFile()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading history: %v", err)
+	}
+
+	var entries []HistoryEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		os.Rename(filename, filename+".bak")
+		return fmt.Errorf("error parsing history, moved it to %s.bak: %v", filename, err)
+	}
+	historyMu.Lock()
+	history = entries
+	historyMu.Unlock()
+	return nil
+}
+
+// appendHistory records a visit and writes the history back to disk,
+// keeping at most maxHistoryEntries. Credentials in the URL are dropped.
+func appendHistory(entry HistoryEntry) error {
+	entry.URL = displayURL(entry.URL)
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	history = append(history, entry)
+	if len(history) > maxHistoryEntries {
+		history = append([]HistoryEntry(nil), history[len(history)-maxHistoryEntries:]...)
+	}
+
+	filename, err := historyFile()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding history: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return fmt.Errorf("error creating data dir: %v", err)
+	}
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		return fmt.Errorf("error writing history: %v", err)
+	}
+	return nil
+}
+
+// showHistory lists past visits, newest first. Enter opens one.
+func (b *browser) showHistory() {
+	historyMu.Lock()
+	entries := make([]HistoryEntry, len(history))
+	copy(entries, history)
+	historyMu.Unlock()
+
+	if len(entries) == 0 {
+		b.setStatus("No history yet")
+		return
+	}
+
+	list := tview.NewList()
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		title := entry.Title
+		if title == "" {
+			title = entry.URL
+		}
+		list.AddItem(tview.Escape(title), activeTheme.muted+entry.Time.Format("2006-01-02 15:04")+"  "+tview.Escape(entry.URL), 0, nil)
+	}
+
+	list.SetSelectedFunc(func(index int, _, _ string, _ rune) {
+		b.closeOverlay("history")
+		b.openURL(entries[len(entries)-1-index].URL)
+	})
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape || event.Rune() == 'q' {
+			b.closeOverlay("history")
+			return nil
+		}
+		return event
+	})
+
+	list.SetBorder(true).SetTitle(fmt.Sprintf(" history (%d visits, Enter: open) ", len(entries)))
+	b.showOverlay("history", list)
+}
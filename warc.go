@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// warcRecord is a captured HTTP response replayed from a WARC file.
+type warcRecord struct {
+	StatusCode  int
+	Status      string
+	ContentType string
+	Body        []byte
+}
+
+// warcArchive is the WARC file loaded with -warc, keyed by target URL.
+// When warcOnly is set, URLs missing from it fail instead of falling
+// through to the network.
+var (
+	warcArchive map[string]*warcRecord
+	warcOnly    bool
+)
+
+// loadWARC reads every response record of the WARC file at path, which
+// may be gzip-compressed. A URL captured more than once keeps its last
+// response.
+func loadWARC(path string) (map[string]*warcRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening WARC: %v", err)
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing WARC: %v", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	records := make(map[string]*warcRecord)
+	reader := bufio.NewReader(r)
+	for {
+		headers, block, err := readWARCRecord(reader)
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		target := strings.Trim(headers["warc-target-uri"], "<>")
+		if headers["warc-type"] != "response" || target == "" {
+			continue
+		}
+		record, err := parseHTTPResponse(block)
+		if err != nil {
+			// Responses for other protocols, e.g. DNS, are skipped
+			continue
+		}
+		records[target] = record
+	}
+}
+
+// readWARCRecord reads one record: its named fields, lower-cased, and its
+// content block.
+func readWARCRecord(reader *bufio.Reader) (map[string]string, []byte, error) {
+	// Skip the blank lines separating records
+	var version string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && strings.TrimSpace(line) == "" {
+				return nil, nil, io.EOF
+			}
+			return nil, nil, fmt.Errorf("error reading WARC record: %v", err)
+		}
+		if version = strings.TrimSpace(line); version != "" {
+			break
+		}
+	}
+	if !strings.HasPrefix(version, "WARC/") {
+		return nil, nil, fmt.Errorf("error reading WARC record: unexpected line %q", version)
+	}
+
+	headers := make(map[string]string)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading WARC headers: %v", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		if name, value, ok := strings.Cut(line, ":"); ok {
+			headers[strings.ToLower(strings.TrimSpace(name))] = strings.TrimSpace(value)
+		}
+	}
+
+	length, err := strconv.ParseInt(headers["content-length"], 10, 64)
+	if err != nil || length < 0 {
+		return nil, nil, fmt.Errorf("error reading WARC record: bad Content-Length %q", headers["content-length"])
+	}
+	block := make([]byte, length)
+	if _, err := io.ReadFull(reader, block); err != nil {
+		return nil, nil, fmt.Errorf("error reading WARC block: %v", err)
+	}
+	return headers, block, nil
+}
+
+func parseHTTPResponse(block []byte) (*warcRecord, error) {
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(block)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing captured response: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading captured body: %v", err)
+	}
+	return &warcRecord{
+		StatusCode:  resp.StatusCode,
+		Status:      resp.Status,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        body,
+	}, nil
+}
+
+// lookupWARC finds the capture of targetURL, also trying it with and
+// without a trailing slash since crawlers record either form.
+func lookupWARC(targetURL string) (*warcRecord, bool) {
+	if record, ok := warcArchive[targetURL]; ok {
+		return record, true
+	}
+	alternate := targetURL + "/"
+	if strings.HasSuffix(targetURL, "/") {
+		alternate = strings.TrimSuffix(targetURL, "/")
+	}
+	record, ok := warcArchive[alternate]
+	return record, ok
+}
+
+// fetchFromWARC serves targetURL from the loaded archive. The boolean is
+// false when the archive does not decide the request and the network
+// should be used instead.
+func fetchFromWARC(targetURL string) (string, bool, error) {
+	if warcArchive == nil {
+		return "", false, nil
+	}
+	record, ok := lookupWARC(targetURL)
+	if !ok {
+		if warcOnly {
+			return "", true, fmt.Errorf("%s is not in the WARC archive", targetURL)
+		}
+		return "", false, nil
+	}
+	if record.StatusCode != http.StatusOK {
+		return "", true, &statusError{Code: record.StatusCode, Status: record.Status}
+	}
+	body, err := decodeCharset(record.Body, record.ContentType)
+	return feedAsHTML(body, record.ContentType), true, err
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// blockElements start and end a line when whitespace follows HTML rules.
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true,
+	"dd": true, "div": true, "dl": true, "dt": true, "fieldset": true,
+	"figcaption": true, "figure": true, "footer": true, "form": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"header": true, "hr": true, "li": true, "main": true, "nav": true,
+	"ol": true, "p": true, "pre": true, "section": true, "table": true,
+	"tr": true, "ul": true,
+}
+
+// htmlWhitespace reports whether text is laid out following HTML
+// whitespace rules instead of one line per text node.
+func htmlWhitespace() bool {
+	return config.Whitespace == "html"
+}
+
+func isBlockElement(n *html.Node) bool {
+	return n.Type == html.ElementNode && blockElements[n.Data]
+}
+
+// spaceReplacer turns the non-breaking spaces &nbsp; and friends decode to
+// into plain spaces, so text containing them still wraps.
+var spaceReplacer = strings.NewReplacer("\u00a0", " ", "\u202f", " ", "\u2007", " ")
+
+// normalizeSpaces replaces non-breaking spaces in s with plain ones. It
+// runs after whitespace is collapsed, so runs of &nbsp; keep their width.
+func normalizeSpaces(s string) string {
+	return spaceReplacer.Replace(s)
+}
+
+func isHTMLSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\f' || r == '\r'
+}
+
+// collapseWhitespace replaces every run of HTML whitespace in s with a
+// single space. Leading and trailing runs are kept as one space because
+// they separate s from neighbouring inline content.
+func collapseWhitespace(s string) string {
+	var out strings.Builder
+	space := false
+	for _, r := range s {
+		if isHTMLSpace(r) {
+			space = true
+			continue
+		}
+		if space {
+			out.WriteByte(' ')
+			space = false
+		}
+		out.WriteRune(r)
+	}
+	if space {
+		out.WriteByte(' ')
+	}
+	return out.String()
+}
+
+// joinInline appends next to text, dropping trailing spaces from text
+// when next starts a new line.
+func joinInline(text, next string) string {
+	if strings.HasPrefix(next, "\n") {
+		text = strings.TrimRight(text, " ")
+	}
+	return text + next
+}
+
+// breakLine ends text's last line unless it is empty or already ended,
+// counting the new line in lineCount.
+func breakLine(text string, lineCount *int) string {
+	if text == "" || strings.HasSuffix(text, "\n") {
+		return text
+	}
+	*lineCount++
+	return strings.TrimRight(text, " ") + "\n"
+}
+
+// endParagraph ends text with a blank line, as below a paragraph.
+func endParagraph(text string, lineCount *int) string {
+	text = breakLine(text, lineCount)
+	if text == "" || strings.HasSuffix(text, "\n\n") {
+		return text
+	}
+	*lineCount++
+	return text + "\n"
+}
+
+// isElement reports whether n is an element with the given tag.
+func isElement(n *html.Node, tag string) bool {
+	return n.Type == html.ElementNode && n.Data == tag
+}
+
+// keepEdgeSpaces restores the single spaces raw had around trimmed, so an
+// inline element stays separated from the words next to it.
+func keepEdgeSpaces(raw, trimmed string) string {
+	if trimmed == "" {
+		return raw
+	}
+	if strings.HasPrefix(raw, " ") {
+		trimmed = " " + trimmed
+	}
+	if strings.HasSuffix(raw, " ") {
+		trimmed += " "
+	}
+	return trimmed
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInlineElementBoundaries(t *testing.T) {
+	tests := []struct {
+		name, doc, want string
+	}{
+		{"space before and after a link", `<p>Read <a href=/x>the docs</a> now.</p>`, "Read [1] the docs now."},
+		{"no space inside a word", `<p>un<b>believ</b>able</p>`, "unbelievable"},
+		{"space between inline elements", `<p><b>bold</b> <i>italic</i></p>`, "bold italic"},
+		{"spaces inside inline elements", `<p>a<b> b </b>c</p>`, "a b c"},
+		{"runs and newlines collapse", "<p>Hello,   <em>\n   world</em>!</p>", "Hello, world!"},
+		{"leading space in a sibling", `<p><span>one</span><span> two</span></p>`, "one two"},
+		{"punctuation after code", `<p>x <code>y</code>, z</p>`, "x y, z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripTags(renderPage(t, "html", tt.doc).Text); got != tt.want+"\n\n" {
+				t.Errorf("rendered %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollapseWhitespace(t *testing.T) {
+	tests := map[string]string{
+		"a  b":           "a b",
+		"\n\ta\r\n b ":   " a b ",
+		"a\u00a0\u00a0b": "a\u00a0\u00a0b",
+		"":               "",
+	}
+	for in, want := range tests {
+		if got := collapseWhitespace(in); got != want {
+			t.Errorf("collapseWhitespace(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestEntitiesAreDecoded(t *testing.T) {
+	doc := `<html><head><title>Q&amp;A &#8212; FAQ</title></head><body>
+		<p>Fish &amp; chips&mdash;it&#8217;s &#x201C;great&#X201d;&nbsp;really &amp;amp;</p>
+		<p><img src=/x.png alt="Tom&nbsp;&amp;&#32;Jerry &#x2014; poster"></p>
+		<p><a href="/q?a=1&amp;b=2">A&nbsp;&lt;link&gt;</a></p>
+	</body></html>`
+	for _, whitespace := range []string{"lines", "html"} {
+		t.Run(whitespace, func(t *testing.T) {
+			page := renderPage(t, whitespace, doc)
+			text := stripTags(page.Text)
+			for _, want := range []string{
+				"Fish & chips—it’s “great” really &amp;", // named, decimal, hex and nbsp
+				"Tom & Jerry — poster",                   // alt text
+				"A <link>",                               // link text
+			} {
+				if !strings.Contains(text, want) {
+					t.Errorf("rendered text lacks %q:\n%s", want, text)
+				}
+			}
+			if strings.ContainsAny(page.Text, "\u00a0") {
+				t.Errorf("non-breaking spaces left in the text: %q", page.Text)
+			}
+			if page.Title != "Q&A — FAQ" {
+				t.Errorf("Title = %q", page.Title)
+			}
+			if link := page.Links[0]; link.Text != "A <link>" || link.Href != "https://example.com/q?a=1&b=2" {
+				t.Errorf("link = %+v", link)
+			}
+			if alt := page.Images[0].Alt; alt != "Tom & Jerry — poster" {
+				t.Errorf("Alt = %q", alt)
+			}
+		})
+	}
+}
+
+func TestNormalizeSpaces(t *testing.T) {
+	if got := normalizeSpaces("a\u00a0b\u202fc\u2007d\u2009e"); got != "a b c d\u2009e" {
+		t.Errorf("normalizeSpaces = %q", got)
+	}
+}